@@ -0,0 +1,291 @@
+package deep
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Calibrator maps network outputs to calibrated probabilities, fitted on
+// held-out outputs and targets
+type Calibrator interface {
+	// Fit learns the mapping from outputs to targets
+	Fit(outputs, targets [][]float64)
+	// Calibrate returns calibrated probabilities of an output
+	Calibrate(output []float64) []float64
+}
+
+// TemperatureScaling divides logits by a temperature, softening
+// overconfident softmax outputs. Single sigmoid outputs are scaled alike.
+type TemperatureScaling struct {
+	Temperature float64
+	// Independent scales the logit of each output separately, for the
+	// sigmoid outputs of ModeBinary and ModeMultiLabel networks rather
+	// than softmax outputs. training.Calibrate sets it from the mode.
+	Independent bool `json:",omitempty"`
+}
+
+// Fit finds the temperature minimizing the negative log-likelihood
+func (c *TemperatureScaling) Fit(outputs, targets [][]float64) {
+	logT := minimize(func(logT float64) float64 {
+		t := TemperatureScaling{Temperature: math.Exp(logT), Independent: c.Independent}
+		var nll float64
+		for i, o := range outputs {
+			p := t.Calibrate(o)
+			if !t.Independent {
+				nll += crossEntropy(p, targets[i])
+				continue
+			}
+			for j := range p {
+				nll += crossEntropy(p[j:j+1], targets[i][j:j+1])
+			}
+		}
+		return nll
+	}, math.Log(0.05), math.Log(20))
+	c.Temperature = math.Exp(logT)
+}
+
+// Calibrate rescales an output
+func (c *TemperatureScaling) Calibrate(output []float64) []float64 {
+	t := c.Temperature
+	if t == 0 {
+		t = 1
+	}
+	if len(output) == 1 || c.Independent {
+		res := make([]float64, len(output))
+		for i, p := range output {
+			res[i] = Logistic(logit(p)/t, 1)
+		}
+		return res
+	}
+	logits := make([]float64, len(output))
+	for i, p := range output {
+		logits[i] = math.Log(clamp(p)) / t
+	}
+	return Softmax(logits)
+}
+
+// PlattScaling fits a logistic regression on the logit of binary outputs,
+// p' = σ(A·logit(p) + B). With several outputs a single mapping is fitted
+// on all of them.
+type PlattScaling struct {
+	A, B float64
+}
+
+// Fit fits A and B by Newton's method on Platt's smoothed targets
+func (c *PlattScaling) Fit(outputs, targets [][]float64) {
+	var z, y []float64
+	var positives, negatives float64
+	for i, o := range outputs {
+		for j := range o {
+			z = append(z, logit(o[j]))
+			y = append(y, targets[i][j])
+			if targets[i][j] >= 0.5 {
+				positives++
+			} else {
+				negatives++
+			}
+		}
+	}
+	hi, lo := (positives+1)/(positives+2), 1/(negatives+2)
+	for i := range y {
+		if y[i] >= 0.5 {
+			y[i] = hi
+		} else {
+			y[i] = lo
+		}
+	}
+
+	nll := func(a, b float64) float64 {
+		var sum float64
+		for i := range z {
+			sum += crossEntropy([]float64{Logistic(a*z[i]+b, 1)}, y[i:i+1])
+		}
+		return sum
+	}
+	c.A, c.B = 1, 0
+	loss := nll(c.A, c.B)
+	for it := 0; it < 100; it++ {
+		var ga, gb, haa, hab, hbb float64
+		for i := range z {
+			p := Logistic(c.A*z[i]+c.B, 1)
+			d, w := p-y[i], p*(1-p)
+			ga, gb = ga+d*z[i], gb+d
+			haa, hab, hbb = haa+w*z[i]*z[i], hab+w*z[i], hbb+w
+		}
+		// Damp the Hessian to keep the step defined for separable data
+		haa, hbb = haa+1e-9, hbb+1e-9
+		det := haa*hbb - hab*hab
+		if det == 0 {
+			break
+		}
+		da, db := (hbb*ga-hab*gb)/det, (haa*gb-hab*ga)/det
+		// Halve the step until the likelihood improves
+		step := 1.0
+		for ; step > 1e-10; step /= 2 {
+			if l := nll(c.A-step*da, c.B-step*db); l < loss {
+				loss = l
+				break
+			}
+		}
+		if step <= 1e-10 {
+			break
+		}
+		c.A, c.B = c.A-step*da, c.B-step*db
+	}
+}
+
+// Calibrate maps each output
+func (c *PlattScaling) Calibrate(output []float64) []float64 {
+	res := make([]float64, len(output))
+	for i, p := range output {
+		res[i] = Logistic(c.A*logit(p)+c.B, 1)
+	}
+	return res
+}
+
+// IsotonicRegression fits a non-decreasing mapping of binary outputs,
+// interpolating linearly between its points. With several outputs a
+// single mapping is fitted on all of them.
+type IsotonicRegression struct {
+	X, Y []float64
+}
+
+// Fit fits the mapping by pooling adjacent violators
+func (c *IsotonicRegression) Fit(outputs, targets [][]float64) {
+	type block struct{ x, y, weight float64 }
+	var points []block
+	for i, o := range outputs {
+		for j := range o {
+			points = append(points, block{o[j], targets[i][j], 1})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].x < points[j].x })
+
+	var blocks []block
+	for _, p := range points {
+		blocks = append(blocks, p)
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.y < last.y {
+				break
+			}
+			w := prev.weight + last.weight
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{
+				x:      (prev.x*prev.weight + last.x*last.weight) / w,
+				y:      (prev.y*prev.weight + last.y*last.weight) / w,
+				weight: w,
+			})
+		}
+	}
+	c.X, c.Y = make([]float64, len(blocks)), make([]float64, len(blocks))
+	for i, b := range blocks {
+		c.X[i], c.Y[i] = b.x, b.y
+	}
+}
+
+// Calibrate maps each output
+func (c *IsotonicRegression) Calibrate(output []float64) []float64 {
+	res := make([]float64, len(output))
+	for i, p := range output {
+		res[i] = c.interpolate(p)
+	}
+	return res
+}
+
+func (c *IsotonicRegression) interpolate(x float64) float64 {
+	n := len(c.X)
+	switch {
+	case n == 0:
+		return x
+	case x <= c.X[0]:
+		return c.Y[0]
+	case x >= c.X[n-1]:
+		return c.Y[n-1]
+	}
+	i := sort.SearchFloat64s(c.X, x)
+	x0, x1, y0, y1 := c.X[i-1], c.X[i], c.Y[i-1], c.Y[i]
+	return y0 + (x-x0)/(x1-x0)*(y1-y0)
+}
+
+var calibrators = map[string]func() Calibrator{
+	"temperature": func() Calibrator { return &TemperatureScaling{} },
+	"platt":       func() Calibrator { return &PlattScaling{} },
+	"isotonic":    func() Calibrator { return &IsotonicRegression{} },
+}
+
+func marshalCalibrator(c Calibrator) (*typedJSON, error) {
+	var t string
+	switch c.(type) {
+	case *TemperatureScaling:
+		t = "temperature"
+	case *PlattScaling:
+		t = "platt"
+	case *IsotonicRegression:
+		t = "isotonic"
+	default:
+		return nil, fmt.Errorf("cannot marshal calibrator %T", c)
+	}
+	params, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return &typedJSON{t, params}, nil
+}
+
+func unmarshalCalibrator(j *typedJSON) (Calibrator, error) {
+	newCalibrator, ok := calibrators[j.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown calibrator %q", j.Type)
+	}
+	c := newCalibrator()
+	if err := json.Unmarshal(j.Params, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// minProbability bounds probabilities away from 0 and 1 for logarithms
+const minProbability = 1e-12
+
+func clamp(p float64) float64 {
+	return math.Max(minProbability, math.Min(1-minProbability, p))
+}
+
+func logit(p float64) float64 {
+	p = clamp(p)
+	return math.Log(p / (1 - p))
+}
+
+func crossEntropy(p, target []float64) float64 {
+	if len(p) == 1 {
+		return -target[0]*math.Log(clamp(p[0])) - (1-target[0])*math.Log(clamp(1-p[0]))
+	}
+	var sum float64
+	for i := range p {
+		sum -= target[i] * math.Log(clamp(p[i]))
+	}
+	return sum
+}
+
+// minimize finds a minimum of a unimodal f in [a, b] by golden section
+// search
+func minimize(f func(float64) float64, a, b float64) float64 {
+	ratio := (math.Sqrt(5) - 1) / 2
+	c, d := b-ratio*(b-a), a+ratio*(b-a)
+	fc, fd := f(c), f(d)
+	for b-a > 1e-6 {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = f(d)
+		}
+	}
+	return (a + b) / 2
+}
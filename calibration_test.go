@@ -0,0 +1,111 @@
+package deep
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TemperatureScaling(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var outputs, targets [][]float64
+	for i := 0; i < 3000; i++ {
+		logits := []float64{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}
+		target := make([]float64, 3)
+		// Sample the class from the true probabilities
+		p, u := Softmax(logits), r.Float64()
+		for j := range p {
+			if u -= p[j]; u <= 0 || j == 2 {
+				target[j] = 1
+				break
+			}
+		}
+		// Report overconfident probabilities
+		for j := range logits {
+			logits[j] *= 3
+		}
+		outputs, targets = append(outputs, Softmax(logits)), append(targets, target)
+	}
+
+	c := &TemperatureScaling{}
+	c.Fit(outputs, targets)
+	assert.InDelta(t, 3, c.Temperature, 0.3)
+	assert.InDelta(t, 1, Sum(c.Calibrate(outputs[0])), 1e-12)
+
+	assert.Equal(t, []float64{0.5, 0.5}, (&TemperatureScaling{}).Calibrate([]float64{0.5, 0.5}))
+	assert.InDelta(t, Logistic(1, 1), (&TemperatureScaling{Temperature: 2}).Calibrate([]float64{Logistic(2, 1)})[0], 1e-12)
+
+	// Independent sigmoid outputs are scaled one by one
+	independent := &TemperatureScaling{Temperature: 2, Independent: true}
+	assert.InDeltaSlice(t, []float64{Logistic(1, 1), Logistic(-2, 1)},
+		independent.Calibrate([]float64{Logistic(2, 1), Logistic(-4, 1)}), 1e-12)
+
+	outputs, targets = nil, nil
+	for i := 0; i < 3000; i++ {
+		output, target := make([]float64, 2), make([]float64, 2)
+		for j := range output {
+			logit := r.NormFloat64()
+			if r.Float64() < Logistic(logit, 1) {
+				target[j] = 1
+			}
+			output[j] = Logistic(3*logit, 1)
+		}
+		outputs, targets = append(outputs, output), append(targets, target)
+	}
+	c = &TemperatureScaling{Independent: true}
+	c.Fit(outputs, targets)
+	assert.InDelta(t, 3, c.Temperature, 0.3)
+}
+
+func Test_PlattScaling(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var outputs, targets [][]float64
+	for i := 0; i < 5000; i++ {
+		z := 2 * r.NormFloat64()
+		var y float64
+		if r.Float64() < Logistic(z, 1) {
+			y = 1
+		}
+		outputs, targets = append(outputs, []float64{Logistic(2*z+1, 1)}), append(targets, []float64{y})
+	}
+
+	c := &PlattScaling{}
+	c.Fit(outputs, targets)
+	assert.InDelta(t, 0.5, c.A, 0.1)
+	assert.InDelta(t, -0.5, c.B, 0.1)
+	assert.InDelta(t, Logistic(c.B, 1), c.Calibrate([]float64{0.5})[0], 1e-12)
+}
+
+func Test_IsotonicRegression(t *testing.T) {
+	c := &IsotonicRegression{}
+	c.Fit([][]float64{{0.3}, {0.1}, {0.4}, {0.2}}, [][]float64{{0}, {0}, {1}, {1}})
+	assert.Equal(t, []float64{0.1, 0.25, 0.4}, c.X)
+	assert.Equal(t, []float64{0, 0.5, 1}, c.Y)
+
+	out := c.Calibrate([]float64{0.05, 0.175, 0.25, 0.9})
+	assert.InDeltaSlice(t, []float64{0, 0.25, 0.5, 1}, out, 1e-12)
+}
+
+func Test_MarshalCalibrator(t *testing.T) {
+	n := NewNeural(&Config{Inputs: 1, Layout: []int{2}, Mode: ModeMultiClass, Weight: NewNormal(1, 0)})
+	for _, c := range []Calibrator{&TemperatureScaling{Temperature: 2}, &PlattScaling{2, 1}, &IsotonicRegression{[]float64{0, 1}, []float64{0.2, 0.8}}} {
+		n.Calibrator = c
+		bytes, err := n.Marshal()
+		assert.NoError(t, err)
+		restored, err := Unmarshal(bytes)
+		assert.NoError(t, err)
+		assert.Equal(t, c, restored.Calibrator)
+		assert.Equal(t, n.Predict([]float64{1}), restored.Predict([]float64{1}))
+	}
+
+	// Predict returns calibrated outputs
+	n.Calibrator = nil
+	raw := n.Predict([]float64{1})
+	n.Calibrator = &PlattScaling{0, 0}
+	assert.Equal(t, []float64{0.5, 0.5}, n.Predict([]float64{1}))
+	assert.NotEqual(t, raw, n.Predict([]float64{1}))
+
+	var d Dump
+	assert.Error(t, d.UnmarshalJSON([]byte(`{"Calibrator":{"Type":"unknown"}}`)))
+}
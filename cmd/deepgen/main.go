@@ -0,0 +1,54 @@
+// Command deepgen generates a standalone Go file from a network dump, as
+// written by deep.Neural.Marshal. It is intended for use with go generate:
+//
+//	//go:generate go run github.com/patrikeh/go-deep/cmd/deepgen -model model.json -out model_gen.go
+//
+// The package name defaults to $GOPACKAGE when run through go generate.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/codegen"
+)
+
+func main() {
+	model := flag.String("model", "", "path to a JSON network dump")
+	out := flag.String("out", "", "output file, defaults to stdout")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	fn := flag.String("func", "Predict", "name of the generated prediction function")
+	flag.Parse()
+
+	if err := run(*model, *out, codegen.Options{Package: *pkg, Func: *fn}); err != nil {
+		fmt.Fprintf(os.Stderr, "deepgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(model, out string, opts codegen.Options) error {
+	if model == "" {
+		return fmt.Errorf("-model is required")
+	}
+	b, err := ioutil.ReadFile(model)
+	if err != nil {
+		return err
+	}
+	var dump deep.Dump
+	if err := json.Unmarshal(b, &dump); err != nil {
+		return err
+	}
+	src, err := codegen.Source(&dump, opts)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,290 @@
+// Package codegen generates standalone Go source from a trained network.
+//
+// The generated file depends only on the standard library, holds the
+// weights as fixed-size arrays and exposes an allocation-free prediction
+// function that reproduces deep.Neural.Predict bit-for-bit.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Options controls the generated source
+type Options struct {
+	// Package name of the generated file, defaults to "model"
+	Package string
+	// Func is the name of the prediction function, defaults to "Predict".
+	// Unexported identifiers in the file are prefixed with it, so that
+	// several models can share a package.
+	Func string
+}
+
+// Generate writes Go source implementing the network in dump to w
+func Generate(w io.Writer, dump *deep.Dump, opts Options) error {
+	src, err := Source(dump, opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Source returns gofmt-ed Go source implementing the network in dump.
+// Dumps with preprocessing or a calibrator are rejected, as the generated
+// function would not reproduce their predictions.
+func Source(dump *deep.Dump, opts Options) ([]byte, error) {
+	if dump == nil || dump.Config == nil {
+		return nil, fmt.Errorf("codegen: dump has no config")
+	}
+	if opts.Package == "" {
+		opts.Package = "model"
+	}
+	if opts.Func == "" {
+		opts.Func = "Predict"
+	}
+	if !isIdentifier(opts.Package) || !isIdentifier(opts.Func) {
+		return nil, fmt.Errorf("codegen: invalid identifiers %q, %q", opts.Package, opts.Func)
+	}
+	if len(dump.Preprocessing) > 0 || dump.Calibrator != nil {
+		return nil, fmt.Errorf("codegen: cannot generate preprocessing or calibration")
+	}
+	if len(dump.Weights) != len(dump.Config.Layout) {
+		return nil, fmt.Errorf("codegen: dump has %d weight layers, config has %d", len(dump.Weights), len(dump.Config.Layout))
+	}
+	width := dump.Config.Inputs
+	for i, neurons := range dump.Weights {
+		if len(neurons) != dump.Config.Layout[i] {
+			return nil, fmt.Errorf("codegen: layer %d: dump has %d neurons, config has %d", i, len(neurons), dump.Config.Layout[i])
+		}
+		// Regression networks have no bias on the output layer
+		if dump.Config.Bias && (dump.Config.Mode != deep.ModeRegression || i < len(dump.Weights)-1) {
+			width++
+		}
+		for j, w := range neurons {
+			if len(w) != width {
+				return nil, fmt.Errorf("codegen: layer %d neuron %d: expected %d weights, got %d", i, j, width, len(w))
+			}
+		}
+		width = len(neurons)
+	}
+
+	n := deep.FromDump(dump)
+	m := &model{
+		Package: opts.Package,
+		Func:    opts.Func,
+		prefix:  lowerFirst(opts.Func),
+		Inputs:  n.Config.Inputs,
+		Layout:  fmt.Sprint(n.Config.Layout),
+		helpers: make(map[deep.ActivationType]bool),
+	}
+	fanIn := n.Config.Inputs
+	for i, l := range n.Layers {
+		lm := layer{
+			Weights: m.prefix + "L" + strconv.Itoa(i),
+			Out:     "a" + strconv.Itoa(i),
+			Size:    len(l.Neurons),
+			FanIn:   fanIn,
+			In:      "in",
+		}
+		if i > 0 {
+			lm.In = m.Layers[i-1].Out
+		}
+		if len(l.Neurons) > 0 {
+			lm.Width = len(l.Neurons[0].In)
+			lm.Bias = lm.Width > fanIn
+		}
+		rows := make([]string, len(l.Neurons))
+		for j, neuron := range l.Neurons {
+			vals := make([]string, len(neuron.In))
+			for k, s := range neuron.In {
+				vals[k] = formatFloat(s.Weight)
+			}
+			rows[j] = "{" + strings.Join(vals, ", ") + "}"
+		}
+		lm.Values = rows
+		m.UsesMath = m.UsesMath || strings.Contains(strings.Join(rows, ""), "math.")
+
+		act := l.A
+		if act == deep.ActivationSoftmax {
+			lm.Softmax = true
+			act = deep.ActivationLinear
+		}
+		var err error
+		if lm.Activation, err = m.activation(act); err != nil {
+			return nil, fmt.Errorf("codegen: layer %d: %v", i, err)
+		}
+		m.UsesMath = m.UsesMath || lm.Softmax || act != deep.ActivationLinear
+		m.Layers = append(m.Layers, lm)
+		fanIn = len(l.Neurons)
+	}
+	m.Outputs = fanIn
+	m.Result = m.Layers[len(m.Layers)-1].Out
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, m); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("codegen: %v", err)
+	}
+	return src, nil
+}
+
+type model struct {
+	Package, Func   string
+	Layout          string
+	UsesMath        bool
+	Inputs, Outputs int
+	Layers          []layer
+	Result          string
+	Helpers         []string
+	prefix          string
+	helpers         map[deep.ActivationType]bool
+}
+
+type layer struct {
+	// Weights is the name of the [Size][Width]float64 weight array, with
+	// the bias (if any) as the last column
+	Weights     string
+	Values      []string
+	In, Out     string
+	Size, FanIn int
+	Width       int
+	Bias        bool
+	Activation  string
+	Softmax     bool
+}
+
+// activation returns the expression template applying act to sum, and
+// registers any helper function it requires. Expressions mirror those of
+// the deep package exactly, so that results are bit-identical.
+func (m *model) activation(act deep.ActivationType) (string, error) {
+	name := m.prefix + activationNames[act]
+	switch act {
+	case deep.ActivationLinear:
+		return "sum", nil
+	case deep.ActivationReLU:
+		return "math.Max(sum, 0)", nil
+	case deep.ActivationSigmoid, deep.ActivationTanh:
+		if !m.helpers[act] {
+			m.helpers[act] = true
+			m.Helpers = append(m.Helpers, fmt.Sprintf(helperSource[act], name))
+		}
+		return name + "(sum)", nil
+	}
+	return "", fmt.Errorf("unsupported activation %d", act)
+}
+
+var activationNames = map[deep.ActivationType]string{
+	deep.ActivationSigmoid: "Sigmoid",
+	deep.ActivationTanh:    "Tanh",
+}
+
+var helperSource = map[deep.ActivationType]string{
+	deep.ActivationSigmoid: "func %s(x float64) float64 { return 1 / (1 + math.Exp(-x)) }",
+	deep.ActivationTanh:    "func %s(x float64) float64 { return (1 - math.Exp(-2*x)) / (1 + math.Exp(-2*x)) }",
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+var tmpl = template.Must(template.New("model").Parse(`// Code generated by deepgen. DO NOT EDIT.
+
+package {{.Package}}
+
+{{if .UsesMath}}import "math"{{end}}
+
+// {{.Func}}Inputs is the input dimension of {{.Func}}
+const {{.Func}}Inputs = {{.Inputs}}
+
+// {{.Func}}Outputs is the output dimension of {{.Func}}
+const {{.Func}}Outputs = {{.Outputs}}
+
+{{range .Layers}}
+var {{.Weights}} = [{{.Size}}][{{.Width}}]float64{
+{{- range .Values}}
+	{{.}},
+{{- end}}
+}
+{{end}}
+// {{.Func}} computes a forward pass of the network with layout {{.Layout}}.
+// in must hold at least {{.Func}}Inputs values.
+func {{.Func}}(in []float64) [{{.Outputs}}]float64 {
+	_ = in[{{.Func}}Inputs-1]
+{{- range .Layers}}
+
+	var {{.Out}} [{{.Size}}]float64
+	for j := range {{.Weights}} {
+		w := &{{.Weights}}[j]
+		var sum float64
+		for k := 0; k < {{.FanIn}}; k++ {
+			sum += float64({{.In}}[k] * w[k])
+		}
+		{{- if .Bias}}
+		sum += w[{{.FanIn}}]
+		{{- end}}
+		{{.Out}}[j] = {{.Activation}}
+	}
+	{{- if .Softmax}}
+	{
+		max := {{.Out}}[0]
+		for _, x := range {{.Out}} {
+			if x > max {
+				max = x
+			}
+		}
+		var sum float64
+		for i, x := range {{.Out}} {
+			{{.Out}}[i] = math.Exp(x - max)
+			sum += {{.Out}}[i]
+		}
+		for i := range {{.Out}} {
+			{{.Out}}[i] /= sum
+		}
+	}
+	{{- end}}
+{{- end}}
+
+	return {{.Result}}
+}
+{{range .Helpers}}
+{{.}}
+{{end}}`))
@@ -0,0 +1,154 @@
+package codegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+const harness = `package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+)
+
+var inputs = %s
+
+func main() {
+	for _, in := range inputs {
+		for _, v := range Predict(in) {
+			fmt.Printf("%%x ", math.Float64bits(v))
+		}
+		fmt.Println()
+	}
+	allocs := testing.AllocsPerRun(100, func() { Predict(inputs[0]) })
+	if allocs != 0 {
+		fmt.Fprintf(os.Stderr, "%%v allocations per call\n", allocs)
+		os.Exit(1)
+	}
+}
+`
+
+func Test_GeneratedMatchesPredict(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+	rand.Seed(0)
+
+	configs := []*deep.Config{
+		{Inputs: 3, Layout: []int{5, 4, 3}, Activation: deep.ActivationReLU, Mode: deep.ModeMultiClass, Bias: true},
+		{Inputs: 2, Layout: []int{4, 1}, Activation: deep.ActivationSigmoid, Mode: deep.ModeBinary, Bias: true},
+		{Inputs: 4, Layout: []int{6, 2}, Activation: deep.ActivationTanh, Mode: deep.ModeRegression, Bias: true},
+		{Inputs: 2, Layout: []int{3, 2}, Activation: deep.ActivationLinear, Bias: false},
+	}
+
+	for i, c := range configs {
+		c.Weight = deep.NewNormal(1, 0)
+		n := deep.NewNeural(c)
+
+		var inputs [][]float64
+		var literals []string
+		for j := 0; j < 20; j++ {
+			in := make([]float64, c.Inputs)
+			for k := range in {
+				in[k] = rand.NormFloat64() * 3
+			}
+			inputs = append(inputs, in)
+			literals = append(literals, fmt.Sprintf("%#v", in))
+		}
+
+		src, err := Source(n.Dump(), Options{Package: "main"})
+		assert.Nil(t, err)
+
+		dir, err := ioutil.TempDir("", "deepgen")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		files := map[string]string{
+			"go.mod":   "module gen\n\ngo 1.13\n",
+			"model.go": string(src),
+			"main.go":  fmt.Sprintf(harness, "[][]float64{"+strings.Join(literals, ", ")+"}"),
+		}
+		for name, content := range files {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+
+		cmd := exec.Command(goBin, "run", ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+		out, err := cmd.Output()
+		if !assert.Nil(t, err, "config %d: %v", i, err) {
+			if ee, ok := err.(*exec.ExitError); ok {
+				t.Log(string(ee.Stderr))
+			}
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		assert.Len(t, lines, len(inputs))
+		for j, line := range lines {
+			var expected []string
+			for _, v := range n.Predict(inputs[j]) {
+				expected = append(expected, fmt.Sprintf("%x", math.Float64bits(v)))
+			}
+			assert.Equal(t, strings.Join(expected, " "), strings.TrimSpace(line), "config %d input %d", i, j)
+		}
+	}
+}
+
+func Test_Source(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{2, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Bias:       true,
+	})
+
+	src, err := Source(n.Dump(), Options{Package: "models", Func: "Classify"})
+	assert.Nil(t, err)
+	s := string(src)
+	assert.Contains(t, s, "package models")
+	assert.Contains(t, s, "func Classify(in []float64) [1]float64")
+	assert.Contains(t, s, "const ClassifyInputs = 2")
+	assert.Contains(t, s, "func classifySigmoid(x float64) float64")
+	assert.Equal(t, 1, strings.Count(s, "func classifySigmoid"))
+
+	_, err = Source(n.Dump(), Options{Func: "not valid"})
+	assert.Error(t, err)
+	_, err = Source(&deep.Dump{}, Options{})
+	assert.Error(t, err)
+
+	n.Calibrator = &deep.TemperatureScaling{Temperature: 2}
+	_, err = Source(n.Dump(), Options{})
+	assert.Error(t, err)
+	n.Calibrator = nil
+	n.Preprocessing = deep.Pipeline{deep.NewStandardScaler()}
+	_, err = Source(n.Dump(), Options{})
+	assert.Error(t, err)
+	n.Preprocessing = nil
+
+	dump := n.Dump()
+	dump.Weights[1][0] = dump.Weights[1][0][:2]
+	_, err = Source(dump, Options{})
+	assert.EqualError(t, err, "codegen: layer 1 neuron 0: expected 3 weights, got 2")
+	dump.Weights[1] = nil
+	_, err = Source(dump, Options{})
+	assert.EqualError(t, err, "codegen: layer 1: dump has 0 neurons, config has 1")
+}
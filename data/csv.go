@@ -0,0 +1,166 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/patrikeh/go-deep/training"
+)
+
+// CSVOptions configures CSV loading
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' by default
+	Comma rune
+	// Header is set if the first row holds column names
+	Header bool
+	// Target is the index of the target column, negative indices count
+	// from the last column
+	Target int
+	// TargetName selects the target column by name, requires Header
+	TargetName string
+	// OneHot encodes the target as a class label, one output per class
+	OneHot bool
+	// Labels fixes the class order when one-hot encoding, by default the
+	// sorted set of labels found. Pass the Classes of a training set when
+	// loading its test set.
+	Labels []string
+}
+
+// CSV is a loaded CSV file
+type CSV struct {
+	// Header holds the column names if the file has a header
+	Header []string
+	// Classes holds the class labels in one-hot order
+	Classes  []string
+	Examples training.Examples
+}
+
+// LoadCSV loads a CSV file of numeric features and a target column
+func LoadCSV(path string, o CSVOptions) (*CSV, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := ReadCSV(f, o)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return res, nil
+}
+
+// ReadCSV reads CSV of numeric features and a target column. Errors
+// report the line of the offending record.
+func ReadCSV(r io.Reader, o CSVOptions) (*CSV, error) {
+	cr := csv.NewReader(r)
+	if o.Comma != 0 {
+		cr.Comma = o.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	res := &CSV{}
+	var records [][]string
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && o.Header {
+			res.Header = record
+			continue
+		}
+		// Records may be preceded by blank lines or span several lines
+		line, _ := cr.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		records = append(records, append(record, strconv.Itoa(line)))
+	}
+
+	target := o.Target
+	if o.TargetName != "" {
+		target = -1
+		for i, name := range res.Header {
+			if strings.TrimSpace(name) == o.TargetName {
+				target = i
+			}
+		}
+		if target < 0 {
+			return nil, fmt.Errorf("data: no column named %q", o.TargetName)
+		}
+	}
+
+	var labels *classes
+	if o.OneHot {
+		res.Classes = o.Labels
+		if res.Classes == nil {
+			set := make(map[string]bool)
+			for _, record := range records {
+				if t, err := column(record[:len(record)-1], target); err == nil {
+					set[strings.TrimSpace(record[t])] = true
+				}
+			}
+			res.Classes = sortLabels(set)
+		}
+		labels = newClasses(res.Classes)
+	}
+
+	var width int
+	for _, record := range records {
+		line := record[len(record)-1]
+		record = record[:len(record)-1]
+		if width == 0 {
+			width = len(record)
+		}
+		if len(record) != width {
+			return nil, fmt.Errorf("data: line %s: expected %d fields, got %d", line, width, len(record))
+		}
+		t, err := column(record, target)
+		if err != nil {
+			return nil, fmt.Errorf("data: line %s: %v", line, err)
+		}
+
+		e := training.Example{Input: make([]float64, 0, len(record)-1)}
+		for i, field := range record {
+			field = strings.TrimSpace(field)
+			if i == t {
+				if labels != nil {
+					response, ok := labels.encode(field)
+					if !ok {
+						return nil, fmt.Errorf("data: line %s: unknown class %q", line, field)
+					}
+					e.Response = response
+					continue
+				}
+			}
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("data: line %s: column %d: invalid number %q", line, i+1, field)
+			}
+			if i == t {
+				e.Response = []float64{v}
+			} else {
+				e.Input = append(e.Input, v)
+			}
+		}
+		res.Examples = append(res.Examples, e)
+	}
+	return res, nil
+}
+
+func column(record []string, i int) (int, error) {
+	if i < 0 {
+		i += len(record)
+	}
+	if i < 0 || i >= len(record) {
+		return 0, fmt.Errorf("target column %d out of range", i)
+	}
+	return i, nil
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrikeh/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadCSV(t *testing.T) {
+	input := "a,b,class\n1,2,b\n3, 4,a\n\n5,6,c\n"
+	res, err := ReadCSV(strings.NewReader(input), CSVOptions{Header: true, Target: -1, OneHot: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "class"}, res.Header)
+	assert.Equal(t, []string{"a", "b", "c"}, res.Classes)
+	assert.Equal(t, training.Examples{
+		{Input: []float64{1, 2}, Response: []float64{0, 1, 0}},
+		{Input: []float64{3, 4}, Response: []float64{1, 0, 0}},
+		{Input: []float64{5, 6}, Response: []float64{0, 0, 1}},
+	}, res.Examples)
+
+	res, err = ReadCSV(strings.NewReader(input), CSVOptions{Header: true, TargetName: "a"})
+	assert.Error(t, err)
+	res, err = ReadCSV(strings.NewReader("a;b\n1;2\n"), CSVOptions{Comma: ';', Header: true, TargetName: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, training.Examples{{Input: []float64{2}, Response: []float64{1}}}, res.Examples)
+}
+
+func Test_ReadCSVLabels(t *testing.T) {
+	// Numeric labels are sorted numerically
+	res, err := ReadCSV(strings.NewReader("10,1\n9,2\n2,3\n"), CSVOptions{OneHot: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "9", "10"}, res.Classes)
+	assert.Equal(t, []float64{0, 0, 1}, res.Examples[0].Response)
+
+	res, err = ReadCSV(strings.NewReader("1,1\n"), CSVOptions{OneHot: true, Labels: []string{"0", "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 1}, res.Examples[0].Response)
+
+	_, err = ReadCSV(strings.NewReader("1,1\n2,1\n"), CSVOptions{OneHot: true, Labels: []string{"0", "1"}})
+	assert.EqualError(t, err, `data: line 2: unknown class "2"`)
+}
+
+func Test_ReadCSVErrors(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("h1,h2\n1,2\n1,x\n"), CSVOptions{Header: true})
+	assert.EqualError(t, err, `data: line 3: column 2: invalid number "x"`)
+
+	_, err = ReadCSV(strings.NewReader("1,2\n1,2,3\n"), CSVOptions{})
+	assert.EqualError(t, err, "data: line 2: expected 2 fields, got 3")
+
+	_, err = ReadCSV(strings.NewReader("1,2\n"), CSVOptions{Target: 2})
+	assert.EqualError(t, err, "data: line 1: target column 2 out of range")
+
+	// Lines are counted through blank lines and quoted line breaks
+	_, err = ReadCSV(strings.NewReader("1,2,3\n\n\n4,5,x\n"), CSVOptions{})
+	assert.EqualError(t, err, `data: line 4: column 3: invalid number "x"`)
+	_, err = ReadCSV(strings.NewReader("\"1\n\",2\n3,x\n"), CSVOptions{})
+	assert.EqualError(t, err, `data: line 3: column 2: invalid number "x"`)
+
+	_, err = LoadCSV("missing.csv", CSVOptions{})
+	assert.Error(t, err)
+}
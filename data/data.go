@@ -0,0 +1,92 @@
+// Package data loads training examples from common file formats
+package data
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// classes one-hot encodes class labels
+type classes struct {
+	labels []string
+	index  map[string]int
+}
+
+func newClasses(labels []string) *classes {
+	c := &classes{labels: labels, index: make(map[string]int, len(labels))}
+	for i, l := range labels {
+		c.index[l] = i
+	}
+	return c
+}
+
+func (c *classes) encode(label string) ([]float64, bool) {
+	i, ok := c.index[label]
+	if !ok {
+		return nil, false
+	}
+	res := make([]float64, len(c.labels))
+	res[i] = 1
+	return res, true
+}
+
+// sortLabels orders labels numerically if they are all numbers, and
+// lexically otherwise
+func sortLabels(set map[string]bool) []string {
+	labels := make([]string, 0, len(set))
+	numeric := true
+	for l := range set {
+		labels = append(labels, l)
+		if _, err := strconv.ParseFloat(l, 64); err != nil {
+			numeric = false
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.ParseFloat(labels[i], 64)
+			b, _ := strconv.ParseFloat(labels[j], 64)
+			return a < b
+		}
+		return labels[i] < labels[j]
+	})
+	return labels
+}
+
+// open opens path, transparently decompressing gzip files
+func open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &gzipFile{gz, f}, nil
+	}
+	return &bufferedFile{r, f}, nil
+}
+
+type bufferedFile struct {
+	*bufio.Reader
+	f *os.File
+}
+
+func (b *bufferedFile) Close() error { return b.f.Close() }
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
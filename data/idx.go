@@ -0,0 +1,156 @@
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/patrikeh/go-deep/training"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+// IDX is an array in the IDX format used by MNIST
+type IDX struct {
+	// Dims holds the size of each dimension, the first being the number
+	// of items
+	Dims []int
+	Data []float64
+}
+
+// Item returns the i:th item, the flattened values of the remaining
+// dimensions
+func (x *IDX) Item(i int) []float64 {
+	size := x.itemSize()
+	return x.Data[i*size : (i+1)*size]
+}
+
+// Len returns the number of items
+func (x *IDX) Len() int {
+	if len(x.Dims) == 0 {
+		return 0
+	}
+	return x.Dims[0]
+}
+
+func (x *IDX) itemSize() int {
+	size := 1
+	for _, d := range x.Dims[1:] {
+		size *= d
+	}
+	return size
+}
+
+// LoadIDX loads an IDX file, which may be gzipped
+func LoadIDX(path string) (*IDX, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := ReadIDX(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return res, nil
+}
+
+// ReadIDX reads an IDX array
+func ReadIDX(r io.Reader) (*IDX, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, fmt.Errorf("data: reading header: %v", err)
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return nil, errors.New("data: not an IDX file")
+	}
+	size, ok := map[byte]int{0x08: 1, 0x09: 1, 0x0B: 2, 0x0C: 4, 0x0D: 4, 0x0E: 8}[magic[2]]
+	if !ok {
+		return nil, fmt.Errorf("data: unknown IDX type 0x%02x", magic[2])
+	}
+
+	dims := make([]uint32, magic[3])
+	if err := binary.Read(r, binary.BigEndian, dims); err != nil {
+		return nil, fmt.Errorf("data: reading dimensions: %v", err)
+	}
+	res := &IDX{Dims: make([]int, len(dims))}
+	total := 1
+	for i, d := range dims {
+		res.Dims[i] = int(d)
+		if d != 0 && uint64(total) > uint64(maxInt/size)/uint64(d) {
+			return nil, fmt.Errorf("data: dimensions %v too large", dims)
+		}
+		total *= int(d)
+	}
+
+	// Reading up to the expected size rather than allocating it up front
+	// keeps corrupt headers from exhausting memory
+	buf, err := ioutil.ReadAll(io.LimitReader(r, int64(total*size)))
+	if err != nil {
+		return nil, fmt.Errorf("data: reading values: %v", err)
+	}
+	if len(buf) < total*size {
+		return nil, fmt.Errorf("data: expected %d values, got %d", total, len(buf)/size)
+	}
+	res.Data = make([]float64, total)
+	for i := range res.Data {
+		b := buf[i*size : (i+1)*size]
+		switch magic[2] {
+		case 0x08:
+			res.Data[i] = float64(b[0])
+		case 0x09:
+			res.Data[i] = float64(int8(b[0]))
+		case 0x0B:
+			res.Data[i] = float64(int16(binary.BigEndian.Uint16(b)))
+		case 0x0C:
+			res.Data[i] = float64(int32(binary.BigEndian.Uint32(b)))
+		case 0x0D:
+			res.Data[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case 0x0E:
+			res.Data[i] = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+	}
+	return res, nil
+}
+
+// LoadMNIST loads MNIST style image and label IDX files, scaling pixels
+// to [0, 1] and one-hot encoding the labels 0-9
+func LoadMNIST(images, labels string) (training.Examples, error) {
+	x, err := LoadIDX(images)
+	if err != nil {
+		return nil, err
+	}
+	y, err := LoadIDX(labels)
+	if err != nil {
+		return nil, err
+	}
+	return mnist(x, y)
+}
+
+func mnist(x, y *IDX) (training.Examples, error) {
+	if len(x.Dims) < 2 || len(y.Dims) != 1 {
+		return nil, errors.New("data: expected images of at least 2 and labels of 1 dimension")
+	}
+	if x.Len() != y.Len() {
+		return nil, fmt.Errorf("data: %d images but %d labels", x.Len(), y.Len())
+	}
+
+	res := make(training.Examples, x.Len())
+	for i := range res {
+		label := int(y.Data[i])
+		if label < 0 || label > 9 {
+			return nil, fmt.Errorf("data: label %d of item %d out of range", label, i)
+		}
+		input := make([]float64, x.itemSize())
+		for j, v := range x.Item(i) {
+			input[j] = v / 255
+		}
+		response := make([]float64, 10)
+		response[label] = 1
+		res[i] = training.Example{Input: input, Response: response}
+	}
+	return res, nil
+}
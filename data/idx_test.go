@@ -0,0 +1,77 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func idx(kind byte, dims []uint32, data interface{}) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, kind, byte(len(dims))})
+	binary.Write(&buf, binary.BigEndian, dims)
+	binary.Write(&buf, binary.BigEndian, data)
+	return buf.Bytes()
+}
+
+func Test_ReadIDX(t *testing.T) {
+	x, err := ReadIDX(bytes.NewReader(idx(0x08, []uint32{2, 2, 3}, []uint8{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 255})))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 2, 3}, x.Dims)
+	assert.Equal(t, 2, x.Len())
+	assert.Equal(t, []float64{6, 7, 8, 9, 10, 255}, x.Item(1))
+
+	x, err = ReadIDX(bytes.NewReader(idx(0x0B, []uint32{2}, []int16{-2, 300})))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{-2, 300}, x.Data)
+
+	x, err = ReadIDX(bytes.NewReader(idx(0x0D, []uint32{1}, []float32{1.5})))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5}, x.Data)
+
+	_, err = ReadIDX(bytes.NewReader(idx(0x08, []uint32{3}, []uint8{1, 2})))
+	assert.EqualError(t, err, "data: expected 3 values, got 2")
+	_, err = ReadIDX(bytes.NewReader([]byte{0, 0, 0x01, 1}))
+	assert.EqualError(t, err, "data: unknown IDX type 0x01")
+	_, err = ReadIDX(bytes.NewReader([]byte{1, 2}))
+	assert.Error(t, err)
+
+	// Corrupt headers fail without allocating their claimed size
+	_, err = ReadIDX(bytes.NewReader(idx(0x08, []uint32{0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF}, []uint8{})))
+	assert.Error(t, err)
+	_, err = ReadIDX(bytes.NewReader(idx(0x0E, []uint32{1 << 30}, []uint8{1})))
+	assert.EqualError(t, err, "data: expected 1073741824 values, got 0")
+}
+
+func Test_LoadMNIST(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mnist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	images := filepath.Join(dir, "images.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(idx(0x08, []uint32{2, 1, 2}, []uint8{0, 255, 51, 0}))
+	gz.Close()
+	assert.NoError(t, ioutil.WriteFile(images, buf.Bytes(), 0644))
+	labels := filepath.Join(dir, "labels")
+	assert.NoError(t, ioutil.WriteFile(labels, idx(0x08, []uint32{2}, []uint8{3, 9}), 0644))
+
+	examples, err := LoadMNIST(images, labels)
+	assert.NoError(t, err)
+	assert.Len(t, examples, 2)
+	assert.Equal(t, []float64{0, 1}, examples[0].Input)
+	assert.Equal(t, []float64{0.2, 0}, examples[1].Input)
+	assert.Equal(t, []float64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0}, examples[0].Response)
+	assert.Equal(t, 1.0, examples[1].Response[9])
+
+	assert.NoError(t, ioutil.WriteFile(labels, idx(0x08, []uint32{1}, []uint8{3}), 0644))
+	_, err = LoadMNIST(images, labels)
+	assert.EqualError(t, err, "data: 2 images but 1 labels")
+}
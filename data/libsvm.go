@@ -0,0 +1,173 @@
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/patrikeh/go-deep/training"
+)
+
+// LIBSVMOptions configures LIBSVM loading
+type LIBSVMOptions struct {
+	// Features is the number of input features, inferred from the largest
+	// feature index if 0
+	Features int
+	// ZeroBased is set if feature indices start at 0 rather than 1
+	ZeroBased bool
+	// Labels one-hot encodes the label in this order if set, otherwise the
+	// label is used as the single response
+	Labels []string
+}
+
+// LoadLIBSVM loads a file in the sparse LIBSVM format
+func LoadLIBSVM(path string, o LIBSVMOptions) (training.Examples, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := ReadLIBSVM(f, o)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return res, nil
+}
+
+// ReadLIBSVM reads lines of the form "<label> <index>:<value> ...",
+// skipping blank lines and comments. Features left out are 0.
+func ReadLIBSVM(r io.Reader, o LIBSVMOptions) (training.Examples, error) {
+	type sparse struct {
+		response []float64
+		index    []int
+		value    []float64
+	}
+
+	var rows []sparse
+	features := o.Features
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	parser := o.parser()
+	for line := 1; scanner.Scan(); line++ {
+		response, index, value, err := parser(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("data: line %d: %v", line, err)
+		}
+		if response == nil {
+			continue
+		}
+		for _, i := range index {
+			if o.Features > 0 && i >= o.Features {
+				return nil, fmt.Errorf("data: line %d: feature index %d exceeds %d features", line, o.index(i), o.Features)
+			}
+			if i >= features {
+				features = i + 1
+			}
+		}
+		rows = append(rows, sparse{response, index, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make(training.Examples, len(rows))
+	for i, row := range rows {
+		input := make([]float64, features)
+		for j, k := range row.index {
+			input[k] = row.value[j]
+		}
+		res[i] = training.Example{Input: input, Response: row.response}
+	}
+	return res, nil
+}
+
+// ParseLIBSVM returns a line parser for training.NewLineDataset, streaming
+// LIBSVM files too large to load. Features must be set.
+func ParseLIBSVM(o LIBSVMOptions) func(line string) (training.Example, error) {
+	parser := o.parser()
+	return func(line string) (training.Example, error) {
+		if o.Features <= 0 {
+			return training.Example{}, fmt.Errorf("number of features not set")
+		}
+		response, index, value, err := parser(line)
+		if err != nil {
+			return training.Example{}, err
+		}
+		input := make([]float64, o.Features)
+		for j, k := range index {
+			if k >= o.Features {
+				return training.Example{}, fmt.Errorf("feature index %d exceeds %d features", o.index(k), o.Features)
+			}
+			input[k] = value[j]
+		}
+		return training.Example{Input: input, Response: response}, nil
+	}
+}
+
+func (o LIBSVMOptions) index(i int) int {
+	if o.ZeroBased {
+		return i
+	}
+	return i + 1
+}
+
+// parser returns a parser of single lines, returning a nil response for
+// lines without an example
+func (o LIBSVMOptions) parser() func(line string) ([]float64, []int, []float64, error) {
+	var labels *classes
+	if o.Labels != nil {
+		labels = newClasses(o.Labels)
+	}
+	offset := 1
+	if o.ZeroBased {
+		offset = 0
+	}
+	return func(line string) ([]float64, []int, []float64, error) {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return nil, nil, nil, nil
+		}
+
+		var response []float64
+		if labels != nil {
+			var ok bool
+			if response, ok = labels.encode(fields[0]); !ok {
+				return nil, nil, nil, fmt.Errorf("unknown class %q", fields[0])
+			}
+		} else {
+			v, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid label %q", fields[0])
+			}
+			response = []float64{v}
+		}
+
+		index := make([]int, 0, len(fields)-1)
+		value := make([]float64, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			colon := strings.IndexByte(field, ':')
+			if colon < 0 {
+				return nil, nil, nil, fmt.Errorf("invalid feature %q", field)
+			}
+			if field[:colon] == "qid" {
+				continue
+			}
+			i, err := strconv.Atoi(field[:colon])
+			if err != nil || i < offset {
+				return nil, nil, nil, fmt.Errorf("invalid feature index %q", field[:colon])
+			}
+			v, err := strconv.ParseFloat(field[colon+1:], 64)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid feature value %q", field[colon+1:])
+			}
+			index = append(index, i-offset)
+			value = append(value, v)
+		}
+		return response, index, value, nil
+	}
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrikeh/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadLIBSVM(t *testing.T) {
+	input := "# comment\n1 1:0.5 3:2\n\n-1 qid:3 2:1 # trailing\n"
+	res, err := ReadLIBSVM(strings.NewReader(input), LIBSVMOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, training.Examples{
+		{Input: []float64{0.5, 0, 2}, Response: []float64{1}},
+		{Input: []float64{0, 1, 0}, Response: []float64{-1}},
+	}, res)
+
+	res, err = ReadLIBSVM(strings.NewReader(input), LIBSVMOptions{Features: 4, ZeroBased: true, Labels: []string{"-1", "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, training.Examples{
+		{Input: []float64{0, 0.5, 0, 2}, Response: []float64{0, 1}},
+		{Input: []float64{0, 0, 1, 0}, Response: []float64{1, 0}},
+	}, res)
+}
+
+func Test_ReadLIBSVMErrors(t *testing.T) {
+	for input, expected := range map[string]string{
+		"1 1:1\nx 1:1\n": `data: line 2: invalid label "x"`,
+		"1 0:1\n":        `data: line 1: invalid feature index "0"`,
+		"1 1:a\n":        `data: line 1: invalid feature value "a"`,
+		"1 1\n":          `data: line 1: invalid feature "1"`,
+		"1 4:1\n":        "data: line 1: feature index 4 exceeds 3 features",
+	} {
+		_, err := ReadLIBSVM(strings.NewReader(input), LIBSVMOptions{Features: 3})
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func Test_ParseLIBSVM(t *testing.T) {
+	parse := ParseLIBSVM(LIBSVMOptions{Features: 2})
+	e, err := parse("0 2:3")
+	assert.NoError(t, err)
+	assert.Equal(t, training.Example{Input: []float64{0, 3}, Response: []float64{0}}, e)
+
+	_, err = parse("0 3:3")
+	assert.EqualError(t, err, "feature index 3 exceeds 2 features")
+	_, err = ParseLIBSVM(LIBSVMOptions{})("0 1:1")
+	assert.Error(t, err)
+}
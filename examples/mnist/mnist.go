@@ -1,15 +1,11 @@
 package main
 
 import (
-	"bufio"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"math/rand"
-	"os"
-	"strconv"
 	"time"
 
+	"github.com/patrikeh/go-deep/data"
 	"github.com/patrikeh/go-deep/training"
 
 	deep "github.com/patrikeh/go-deep"
@@ -33,16 +29,6 @@ func main() {
 		panic(err)
 	}
 
-	for i := range train {
-		for j := range train[i].Input {
-			train[i].Input[j] = train[i].Input[j] / 255
-		}
-	}
-	for i := range test {
-		for j := range test[i].Input {
-			test[i].Input[j] = test[i].Input[j] / 255
-		}
-	}
 	test.Shuffle()
 	train.Shuffle()
 
@@ -64,48 +50,15 @@ func main() {
 }
 
 func load(path string) (training.Examples, error) {
-	f, err := os.Open(path)
-	defer f.Close()
+	digits := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	res, err := data.LoadCSV(path, data.CSVOptions{OneHot: true, Labels: digits})
 	if err != nil {
 		return nil, err
 	}
-	r := csv.NewReader(bufio.NewReader(f))
-
-	var examples training.Examples
-	for {
-		record, err := r.Read()
-		if err == io.EOF {
-			break
+	for i := range res.Examples {
+		for j := range res.Examples[i].Input {
+			res.Examples[i].Input[j] /= 255
 		}
-		examples = append(examples, toExample(record))
 	}
-
-	return examples, nil
-}
-
-func toExample(in []string) training.Example {
-	res, err := strconv.ParseFloat(in[0], 64)
-	if err != nil {
-		panic(err)
-	}
-	resEncoded := onehot(10, res)
-	var features []float64
-	for i := 1; i < len(in); i++ {
-		res, err := strconv.ParseFloat(in[i], 64)
-		if err != nil {
-			panic(err)
-		}
-		features = append(features, res)
-	}
-
-	return training.Example{
-		Response: resEncoded,
-		Input:    features,
-	}
-}
-
-func onehot(classes int, val float64) []float64 {
-	res := make([]float64, classes)
-	res[int(val)] = 1
-	return res
+	return res.Examples, nil
 }
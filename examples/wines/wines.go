@@ -1,16 +1,12 @@
 package main
 
 import (
-	"bufio"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"math/rand"
-	"os"
-	"strconv"
 	"time"
 
 	"github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/data"
 	"github.com/patrikeh/go-deep/training"
 )
 
@@ -18,20 +14,20 @@ func main() {
 
 	rand.Seed(time.Now().UnixNano())
 
-	data, err := load("./wine.data")
+	wines, err := data.LoadCSV("./wine.data", data.CSVOptions{OneHot: true})
 	if err != nil {
 		panic(err)
 	}
+	// Standardize each feature, keeping the scaler with the model so that
+	// it predicts from raw features
+	scaler := deep.NewStandardScaler()
+	examples := wines.Examples.Fit(scaler)
+	examples.Shuffle()
 
-	for i := range data {
-		deep.Standardize(data[i].Input)
-	}
-	data.Shuffle()
-
-	fmt.Printf("have %d entries\n", len(data))
+	fmt.Printf("have %d entries\n", len(examples))
 
 	neural := deep.NewNeural(&deep.Config{
-		Inputs:     len(data[0].Input),
+		Inputs:     len(examples[0].Input),
 		Layout:     []int{8, 3},
 		Activation: deep.ActivationTanh,
 		Mode:       deep.ModeMultiClass,
@@ -42,54 +38,15 @@ func main() {
 	//trainer := training.NewTrainer(training.NewSGD(0.005, 0.5, 1e-6, true), 50)
 	//trainer := training.NewBatchTrainer(training.NewSGD(0.005, 0.1, 0, true), 50, 300, 16)
 	//trainer := training.NewTrainer(training.NewAdam(0.1, 0, 0, 0), 50)
-	trainer := training.NewBatchTrainer(training.NewAdam(0.1, 0, 0, 0), 50, len(data)/2, 12)
-	//data, heldout := data.Split(0.5)
-	trainer.Train(neural, data, data, 5000)
-}
-
-func load(path string) (training.Examples, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	r := csv.NewReader(bufio.NewReader(f))
-
-	var examples training.Examples
-	for {
-		record, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		examples = append(examples, toExample(record))
-	}
-
-	return examples, nil
-}
-
-func toExample(in []string) training.Example {
-	res, err := strconv.ParseFloat(in[0], 64)
+	trainer := training.NewBatchTrainer(training.NewAdam(0.1, 0, 0, 0), 50, len(examples)/2, 12)
+	trainer.SetRegularization(training.Regularization{L2: 1e-3})
+	trainer.AddCallback(training.NewEarlyStopping("loss", 200, 1e-4, false))
+	//examples, heldout := examples.Split(0.5)
+	trainer.Train(neural, examples, examples, 5000)
+	neural.Preprocessing = deep.Pipeline{scaler}
+	report, err := training.EvaluateWith(neural, examples, training.ReportOptions{Labels: wines.Classes})
 	if err != nil {
 		panic(err)
 	}
-	resEncoded := onehot(3, res)
-	var features []float64
-	for i := 1; i < len(in); i++ {
-		res, err := strconv.ParseFloat(in[i], 64)
-		if err != nil {
-			panic(err)
-		}
-		features = append(features, res)
-	}
-
-	return training.Example{
-		Response: resEncoded,
-		Input:    features,
-	}
-}
-
-func onehot(classes int, val float64) []float64 {
-	res := make([]float64, classes)
-	res[int(val)-1] = 1
-	return res
+	fmt.Print(report)
 }
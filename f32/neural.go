@@ -0,0 +1,214 @@
+// Package f32 implements a single precision variant of deep.Neural.
+//
+// Networks store their weights as dense float32 matrices, halving memory
+// use and bandwidth compared to the float64 networks of the deep package.
+// They share deep.Config, its activations and losses, and can be converted
+// to and from deep.Neural along with its preprocessing and calibrator.
+//
+// Trainer and BatchTrainer mirror the online and mini-batch trainers of the
+// training package, taking any training.Solver and printing the validation
+// loss to a configurable writer.
+package f32
+
+import (
+	"fmt"
+	"math"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Neural is a single precision neural network
+type Neural struct {
+	Layers []*Layer
+	Config *deep.Config
+	// Preprocessing, if set, transforms raw features to network inputs
+	// in Predict, in double precision as in deep.Neural
+	Preprocessing deep.Pipeline
+	// Calibrator, if set, maps outputs to calibrated probabilities in
+	// Predict
+	Calibrator deep.Calibrator
+}
+
+// Layer is a fully connected layer
+type Layer struct {
+	// Weights is a row-major [neurons][Width] matrix, holding the bias
+	// weight (if any) in the last column
+	Weights []float32
+	// FanIn is the number of inputs to the layer
+	FanIn int
+	// Width is the number of weights per neuron
+	Width int
+	A     deep.ActivationType
+
+	values []float32
+}
+
+// NewNeural returns a new neural network, initialized as by deep.NewNeural
+func NewNeural(c *deep.Config) *Neural {
+	return FromFloat64(deep.NewNeural(c))
+}
+
+// FromFloat64 converts a double precision network, sharing its
+// preprocessing and calibrator
+func FromFloat64(n *deep.Neural) *Neural {
+	layers := make([]*Layer, len(n.Layers))
+	fanIn := n.Config.Inputs
+	for i, l := range n.Layers {
+		var width int
+		if len(l.Neurons) > 0 {
+			width = len(l.Neurons[0].In)
+		}
+		layer := &Layer{
+			Weights: make([]float32, len(l.Neurons)*width),
+			FanIn:   fanIn,
+			Width:   width,
+			A:       l.A,
+			values:  make([]float32, len(l.Neurons)),
+		}
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				layer.Weights[j*width+k] = float32(s.Weight)
+			}
+		}
+		layers[i] = layer
+		fanIn = len(l.Neurons)
+	}
+	return &Neural{
+		Layers:        layers,
+		Config:        n.Config,
+		Preprocessing: n.Preprocessing,
+		Calibrator:    n.Calibrator,
+	}
+}
+
+// Float64 converts n to a double precision network, sharing its
+// preprocessing and calibrator
+func (n *Neural) Float64() *deep.Neural {
+	d := deep.NewNeural(n.Config)
+	d.ApplyWeights(n.weights64())
+	d.Preprocessing = n.Preprocessing
+	d.Calibrator = n.Calibrator
+	return d
+}
+
+// Size is the number of neurons in l
+func (l *Layer) Size() int {
+	return len(l.values)
+}
+
+func (l *Layer) hasBias() bool {
+	return l.Width > l.FanIn
+}
+
+func (l *Layer) fire(in []float32) {
+	for j := range l.values {
+		w := l.Weights[j*l.Width : (j+1)*l.Width]
+		var sum float32
+		for k, x := range in {
+			sum += x * w[k]
+		}
+		if l.hasBias() {
+			sum += w[l.FanIn]
+		}
+		l.values[j] = activate(l.A, sum)
+	}
+	if l.A == deep.ActivationSoftmax {
+		softmax(l.values)
+	}
+}
+
+// Forward computes a forward pass
+func (n *Neural) Forward(input []float32) error {
+	if len(input) != n.Config.Inputs {
+		return fmt.Errorf("Invalid input dimension - expected: %d got: %d", n.Config.Inputs, len(input))
+	}
+	in := input
+	for _, l := range n.Layers {
+		l.fire(in)
+		in = l.values
+	}
+	return nil
+}
+
+// Predict computes a forward pass and returns a prediction. The input is
+// transformed by Preprocessing and the output by Calibrator, if set.
+func (n *Neural) Predict(input []float32) []float32 {
+	if n.Preprocessing != nil {
+		input = toFloat32(n.Preprocessing.Transform(toFloat64(input)))
+	}
+	out := n.output(input)
+	if n.Calibrator != nil {
+		return toFloat32(n.Calibrator.Calibrate(toFloat64(out)))
+	}
+	return out
+}
+
+// output returns the outputs of a forward pass on network inputs
+func (n *Neural) output(input []float32) []float32 {
+	n.Forward(input)
+
+	outLayer := n.Layers[len(n.Layers)-1]
+	out := make([]float32, len(outLayer.values))
+	copy(out, outLayer.values)
+	return out
+}
+
+// NumWeights returns the number of weights in the network
+func (n *Neural) NumWeights() (num int) {
+	for _, l := range n.Layers {
+		num += len(l.Weights)
+	}
+	return
+}
+
+func activate(a deep.ActivationType, x float32) float32 {
+	switch a {
+	case deep.ActivationSigmoid:
+		return 1 / (1 + exp(-x))
+	case deep.ActivationTanh:
+		return (1 - exp(-2*x)) / (1 + exp(-2*x))
+	case deep.ActivationReLU:
+		if x > 0 {
+			return x
+		}
+		return 0
+	}
+	return x
+}
+
+// dactivate is the derivative of a expressed in terms of y = a(x)
+func dactivate(a deep.ActivationType, y float32) float32 {
+	switch a {
+	case deep.ActivationSigmoid:
+		return y * (1 - y)
+	case deep.ActivationTanh:
+		return 1 - y*y
+	case deep.ActivationReLU:
+		if y > 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1
+}
+
+func softmax(xx []float32) {
+	max := xx[0]
+	for _, x := range xx {
+		if x > max {
+			max = x
+		}
+	}
+	var sum float32
+	for i, x := range xx {
+		xx[i] = exp(x - max)
+		sum += xx[i]
+	}
+	for i := range xx {
+		xx[i] /= sum
+	}
+}
+
+func exp(x float32) float32 {
+	return float32(math.Exp(float64(x)))
+}
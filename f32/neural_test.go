@@ -0,0 +1,110 @@
+package f32
+
+import (
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Conversion(t *testing.T) {
+	rand.Seed(0)
+
+	configs := []*deep.Config{
+		{Inputs: 3, Layout: []int{4, 4, 3}, Activation: deep.ActivationReLU, Mode: deep.ModeMultiClass, Bias: true},
+		{Inputs: 2, Layout: []int{5, 1}, Activation: deep.ActivationSigmoid, Mode: deep.ModeBinary, Bias: true},
+		{Inputs: 1, Layout: []int{4, 4, 1}, Activation: deep.ActivationTanh, Mode: deep.ModeRegression, Bias: true},
+		{Inputs: 2, Layout: []int{3, 2}, Activation: deep.ActivationLinear, Bias: false},
+	}
+	for _, c := range configs {
+		c.Weight = deep.NewNormal(1, 0)
+		n64 := deep.NewNeural(c)
+		n32 := FromFloat64(n64)
+
+		assert.Equal(t, n64.NumWeights(), n32.NumWeights())
+		for i := 0; i < 10; i++ {
+			input := make([]float64, c.Inputs)
+			for j := range input {
+				input[j] = rand.NormFloat64()
+			}
+			want := n64.Predict(input)
+			have := n32.Predict(toFloat32(input))
+			for j := range want {
+				assert.InDelta(t, want[j], float64(have[j]), 1e-5)
+			}
+		}
+
+		back := n32.Float64()
+		assert.Equal(t, n32.Weights(), FromFloat64(back).Weights())
+	}
+}
+
+func Test_ConversionTransforms(t *testing.T) {
+	rand.Seed(0)
+	n64 := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	n64.Preprocessing = deep.Pipeline{deep.NewStandardScaler()}
+	n64.Preprocessing.Fit([][]float64{{1, 100}, {3, 300}})
+	n64.Calibrator = &deep.TemperatureScaling{Temperature: 2}
+
+	n32 := FromFloat64(n64)
+	raw := []float64{3, 100}
+	want := n64.Predict(raw)
+	have := n32.Predict(toFloat32(raw))
+	for j := range want {
+		assert.InDelta(t, want[j], float64(have[j]), 1e-5)
+	}
+
+	back := n32.Float64()
+	assert.Equal(t, n64.Preprocessing, back.Preprocessing)
+	assert.Equal(t, n64.Calibrator, back.Calibrator)
+	assert.InDeltaSlice(t, want, back.Predict(raw), 1e-6)
+}
+
+func Test_Forward(t *testing.T) {
+	n := NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{2, 2},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+	})
+	n.ApplyWeights([][][]float32{
+		{{1, 2, 0.5}, {-1, 1, 0}},
+		{{1, 1, 0}, {0, 0, 0}},
+	})
+
+	out := n.Predict([]float32{1, 0})
+	h := []float64{deep.Logistic(1.5, 1), deep.Logistic(-1, 1)}
+	expected := deep.Softmax([]float64{h[0] + h[1], 0})
+	for i := range out {
+		assert.InDelta(t, expected[i], float64(out[i]), 1e-6)
+	}
+
+	assert.Error(t, n.Forward([]float32{1}))
+}
+
+func Benchmark_Predict(b *testing.B) {
+	n := NewNeural(&deep.Config{
+		Inputs:     784,
+		Layout:     []int{50, 10},
+		Activation: deep.ActivationReLU,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+	})
+	input := make([]float32, 784)
+	for i := range input {
+		input[i] = rand.Float32()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n.Predict(input)
+	}
+}
@@ -0,0 +1,125 @@
+package f32
+
+import (
+	"encoding/json"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Dump is a single precision network dump. Its JSON form is that of
+// deep.Dump, so that either precision can restore the other's dumps.
+type Dump struct {
+	Config        *deep.Config
+	Weights       [][][]float32
+	Preprocessing deep.Pipeline
+	Calibrator    deep.Calibrator
+}
+
+// MarshalJSON marshals the dump as deep.Dump does, with single precision
+// weights
+func (d Dump) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(deep.Dump{
+		Config:        d.Config,
+		Preprocessing: d.Preprocessing,
+		Calibrator:    d.Calibrator,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	if fields["Weights"], err = json.Marshal(d.Weights); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON restores a single or double precision dump
+func (d *Dump) UnmarshalJSON(data []byte) error {
+	var dump deep.Dump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return err
+	}
+	d.Config, d.Preprocessing, d.Calibrator = dump.Config, dump.Preprocessing, dump.Calibrator
+	d.Weights = make([][][]float32, len(dump.Weights))
+	for i, l := range dump.Weights {
+		d.Weights[i] = make([][]float32, len(l))
+		for j, w := range l {
+			d.Weights[i][j] = toFloat32(w)
+		}
+	}
+	return nil
+}
+
+// ApplyWeights sets the weights from a three-dimensional slice
+func (n *Neural) ApplyWeights(weights [][][]float32) {
+	for i, l := range n.Layers {
+		for j := 0; j < l.Size(); j++ {
+			copy(l.Weights[j*l.Width:(j+1)*l.Width], weights[i][j])
+		}
+	}
+}
+
+// Weights returns all weights in sequence
+func (n Neural) Weights() [][][]float32 {
+	weights := make([][][]float32, len(n.Layers))
+	for i, l := range n.Layers {
+		weights[i] = make([][]float32, l.Size())
+		for j := range weights[i] {
+			weights[i][j] = make([]float32, l.Width)
+			copy(weights[i][j], l.Weights[j*l.Width:(j+1)*l.Width])
+		}
+	}
+	return weights
+}
+
+func (n Neural) weights64() [][][]float64 {
+	weights := make([][][]float64, len(n.Layers))
+	for i, l := range n.Layers {
+		weights[i] = make([][]float64, l.Size())
+		for j := range weights[i] {
+			weights[i][j] = make([]float64, l.Width)
+			for k := range weights[i][j] {
+				weights[i][j][k] = float64(l.Weights[j*l.Width+k])
+			}
+		}
+	}
+	return weights
+}
+
+// Dump generates a network dump
+func (n Neural) Dump() *Dump {
+	return &Dump{
+		Config:        n.Config,
+		Weights:       n.Weights(),
+		Preprocessing: n.Preprocessing,
+		Calibrator:    n.Calibrator,
+	}
+}
+
+// FromDump restores a Neural from a dump
+func FromDump(dump *Dump) *Neural {
+	n := NewNeural(dump.Config)
+	n.ApplyWeights(dump.Weights)
+	n.Preprocessing = dump.Preprocessing
+	n.Calibrator = dump.Calibrator
+
+	return n
+}
+
+// Marshal marshals to JSON from network
+func (n Neural) Marshal() ([]byte, error) {
+	return json.Marshal(n.Dump())
+}
+
+// Unmarshal restores network from a JSON blob. Both single and double
+// precision dumps are accepted.
+func Unmarshal(bytes []byte) (*Neural, error) {
+	var dump Dump
+	if err := json.Unmarshal(bytes, &dump); err != nil {
+		return nil, err
+	}
+	return FromDump(&dump), nil
+}
@@ -0,0 +1,84 @@
+package f32
+
+import (
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Marshal(t *testing.T) {
+	rand.Seed(0)
+
+	n := NewNeural(&deep.Config{
+		Inputs:     1,
+		Layout:     []int{3, 3, 1},
+		Activation: deep.ActivationSigmoid,
+		Weight:     deep.NewUniform(0.5, 0),
+		Bias:       true,
+	})
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+
+	new, err := Unmarshal(dump)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Weights(), new.Weights())
+	assert.Equal(t, n.Predict([]float32{0.5}), new.Predict([]float32{0.5}))
+}
+
+func Test_UnmarshalFloat64(t *testing.T) {
+	rand.Seed(0)
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+
+	n32, err := Unmarshal(dump)
+	assert.Nil(t, err)
+	assert.Equal(t, FromFloat64(n).Weights(), n32.Weights())
+}
+
+func Test_MarshalTransforms(t *testing.T) {
+	rand.Seed(0)
+
+	n := NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewUniform(0.5, 0),
+		Bias:       true,
+	})
+	n.Preprocessing = deep.Pipeline{deep.NewStandardScaler()}
+	n.Preprocessing.Fit([][]float64{{1, 100}, {3, 300}})
+	n.Calibrator = &deep.PlattScaling{A: 2, B: -1}
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+	new, err := Unmarshal(dump)
+	assert.Nil(t, err)
+	raw := []float32{3, 100}
+	assert.Equal(t, n.Weights(), new.Weights())
+	assert.Equal(t, n.Predict(raw), new.Predict(raw))
+
+	// Double precision networks restore single precision dumps alike
+	n64, err := deep.Unmarshal(dump)
+	assert.Nil(t, err)
+	assert.InDelta(t, float64(n.Predict(raw)[0]), n64.Predict(toFloat64(raw))[0], 1e-6)
+
+	d64, err := n.Float64().Marshal()
+	assert.Nil(t, err)
+	new, err = Unmarshal(d64)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Predict(raw), new.Predict(raw))
+}
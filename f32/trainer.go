@@ -0,0 +1,334 @@
+package f32
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+)
+
+// Example is a single precision input-target pair
+type Example struct {
+	Input    []float32
+	Response []float32
+}
+
+// Examples is a set of input-output pairs
+type Examples []Example
+
+// FromExamples converts double precision examples
+func FromExamples(examples training.Examples) Examples {
+	res := make(Examples, len(examples))
+	for i, e := range examples {
+		res[i] = Example{Input: toFloat32(e.Input), Response: toFloat32(e.Response)}
+	}
+	return res
+}
+
+// Shuffle shuffles slice in-place
+func (e Examples) Shuffle() {
+	for i := range e {
+		j := rand.Intn(i + 1)
+		e[i], e[j] = e[j], e[i]
+	}
+}
+
+func toFloat32(xx []float64) []float32 {
+	res := make([]float32, len(xx))
+	for i, x := range xx {
+		res[i] = float32(x)
+	}
+	return res
+}
+
+// Trainer is an online trainer for single precision networks. Updates
+// are computed by any training.Solver.
+type Trainer struct {
+	solver    training.Solver
+	verbosity int
+	out       io.Writer
+	deltas    [][]float32
+}
+
+// NewTrainer creates a new trainer
+func NewTrainer(solver training.Solver, verbosity int) *Trainer {
+	return &Trainer{
+		solver:    solver,
+		verbosity: verbosity,
+		out:       os.Stdout,
+	}
+}
+
+// SetWriter sets where progress is printed, os.Stdout by default
+func (t *Trainer) SetWriter(w io.Writer) {
+	t.out = w
+}
+
+// Train trains n
+func (t *Trainer) Train(n *Neural, examples, validation Examples, iterations int) {
+	t.deltas = newDeltas(n)
+	t.solver.Init(n.NumWeights())
+
+	p := newProgress(t.out, t.verbosity, n)
+	for i := 1; i <= iterations; i++ {
+		examples.Shuffle()
+		for _, e := range examples {
+			t.learn(n, e, i)
+		}
+		p.epochEnd(n, i, validation)
+	}
+}
+
+func (t *Trainer) learn(n *Neural, e Example, it int) {
+	n.Forward(e.Input)
+	t.calculateDeltas(n, e.Response)
+	t.update(n, e.Input, it)
+}
+
+func (t *Trainer) calculateDeltas(n *Neural, ideal []float32) {
+	backpropagate(n, ideal, t.deltas)
+}
+
+func newDeltas(n *Neural) [][]float32 {
+	deltas := make([][]float32, len(n.Layers))
+	for i, l := range n.Layers {
+		deltas[i] = make([]float32, l.Size())
+	}
+	return deltas
+}
+
+// backpropagate computes the deltas of every layer of n following the
+// last forward pass
+func backpropagate(n *Neural, ideal []float32, deltas [][]float32) {
+	loss := deep.GetLoss(n.Config.Loss)
+	last := n.Layers[len(n.Layers)-1]
+	for j, v := range last.values {
+		deltas[len(n.Layers)-1][j] = float32(loss.Df(
+			float64(v),
+			float64(ideal[j]),
+			float64(dactivate(last.A, v))))
+	}
+	if last.A == deep.ActivationSoftmax && n.Config.Loss == deep.LossMeanSquared {
+		softmaxDeltas(last.values, deltas[len(n.Layers)-1])
+	}
+
+	for i := len(n.Layers) - 2; i >= 0; i-- {
+		l, next := n.Layers[i], n.Layers[i+1]
+		nextD := deltas[i+1]
+		for j, v := range l.values {
+			var sum float32
+			for k, d := range nextD {
+				sum += next.Weights[k*next.Width+j] * d
+			}
+			deltas[i][j] = dactivate(l.A, v) * sum
+		}
+	}
+}
+
+// softmaxDeltas converts the derivatives g of a loss with respect to the
+// softmax outputs y into deltas: δ_j = y_j (g_j - Σ_k y_k g_k)
+func softmaxDeltas(y, g []float32) {
+	var dot float32
+	for k, v := range y {
+		dot += v * g[k]
+	}
+	for j, v := range y {
+		g[j] = v * (g[j] - dot)
+	}
+}
+
+func (t *Trainer) update(n *Neural, input []float32, it int) {
+	var idx int
+	in := input
+	for i, l := range n.Layers {
+		for j, d := range t.deltas[i] {
+			w := l.Weights[j*l.Width : (j+1)*l.Width]
+			for k := range w {
+				x := float32(1)
+				if k < l.FanIn {
+					x = in[k]
+				}
+				w[k] += float32(t.solver.Update(float64(w[k]), float64(d*x), it, idx))
+				idx++
+			}
+		}
+		in = l.values
+	}
+}
+
+// BatchTrainer trains single precision networks on mini-batches, computing
+// the gradients of a batch in parallel. Updates are computed by any
+// training.Solver from the gradients summed over the batch.
+type BatchTrainer struct {
+	solver      training.Solver
+	verbosity   int
+	batchSize   int
+	parallelism int
+	out         io.Writer
+}
+
+// NewBatchTrainer returns a BatchTrainer
+func NewBatchTrainer(solver training.Solver, verbosity, batchSize, parallelism int) *BatchTrainer {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	return &BatchTrainer{
+		solver:      solver,
+		verbosity:   verbosity,
+		batchSize:   batchSize,
+		parallelism: parallelism,
+		out:         os.Stdout,
+	}
+}
+
+// SetWriter sets where progress is printed, os.Stdout by default
+func (t *BatchTrainer) SetWriter(w io.Writer) {
+	t.out = w
+}
+
+// Train trains n
+func (t *BatchTrainer) Train(n *Neural, examples, validation Examples, iterations int) {
+	workers := make([]*worker, t.parallelism)
+	for i := range workers {
+		workers[i] = newWorker(n)
+	}
+	gradients := make([]float32, n.NumWeights())
+	t.solver.Init(n.NumWeights())
+
+	p := newProgress(t.out, t.verbosity, n)
+	for i := 1; i <= iterations; i++ {
+		examples.Shuffle()
+		for start := 0; start < len(examples); start += t.batchSize {
+			end := start + t.batchSize
+			if end > len(examples) {
+				end = len(examples)
+			}
+			batch := examples[start:end]
+
+			var wg sync.WaitGroup
+			for w, wk := range workers {
+				wg.Add(1)
+				go func(w int, wk *worker) {
+					defer wg.Done()
+					for j := w; j < len(batch); j += len(workers) {
+						wk.accumulate(batch[j])
+					}
+				}(w, wk)
+			}
+			wg.Wait()
+
+			for _, wk := range workers {
+				for k, g := range wk.gradients {
+					gradients[k] += g
+					wk.gradients[k] = 0
+				}
+			}
+			var idx int
+			for _, l := range n.Layers {
+				for k, w := range l.Weights {
+					l.Weights[k] += float32(t.solver.Update(float64(w), float64(gradients[idx]), i, idx))
+					gradients[idx] = 0
+					idx++
+				}
+			}
+		}
+		p.epochEnd(n, i, validation)
+	}
+}
+
+// worker accumulates gradients on a copy of a network sharing its weights
+type worker struct {
+	net       *Neural
+	deltas    [][]float32
+	gradients []float32
+}
+
+func newWorker(n *Neural) *worker {
+	layers := make([]*Layer, len(n.Layers))
+	for i, l := range n.Layers {
+		c := *l
+		c.values = make([]float32, l.Size())
+		layers[i] = &c
+	}
+	return &worker{
+		net:       &Neural{Layers: layers, Config: n.Config},
+		deltas:    newDeltas(n),
+		gradients: make([]float32, n.NumWeights()),
+	}
+}
+
+func (w *worker) accumulate(e Example) {
+	w.net.Forward(e.Input)
+	backpropagate(w.net, e.Response, w.deltas)
+
+	var idx int
+	in := e.Input
+	for i, l := range w.net.Layers {
+		for _, d := range w.deltas[i] {
+			for k := 0; k < l.Width; k++ {
+				x := float32(1)
+				if k < l.FanIn {
+					x = in[k]
+				}
+				w.gradients[idx] += d * x
+				idx++
+			}
+		}
+		in = l.values
+	}
+}
+
+// progress prints the validation loss every verbosity epochs
+type progress struct {
+	w         *tabwriter.Writer
+	verbosity int
+	start     time.Time
+}
+
+func newProgress(out io.Writer, verbosity int, n *Neural) *progress {
+	p := &progress{
+		w:         tabwriter.NewWriter(out, 16, 0, 3, ' ', 0),
+		verbosity: verbosity,
+		start:     time.Now(),
+	}
+	if verbosity > 0 {
+		fmt.Fprintf(p.w, "Epochs\tElapsed\tLoss (%s)\t\n---\t---\t---\t\n", n.Config.Loss)
+	}
+	return p
+}
+
+func (p *progress) epochEnd(n *Neural, epoch int, validation Examples) {
+	if p.verbosity > 0 && epoch%p.verbosity == 0 && len(validation) > 0 {
+		fmt.Fprintf(p.w, "%d\t%s\t%.4f\t\n", epoch, time.Since(p.start).String(), Loss(n, validation))
+		p.w.Flush()
+	}
+}
+
+// Loss computes the configured loss of n over examples. Inputs are
+// network inputs, as taken by the trainers, and outputs are taken before
+// any calibration.
+func Loss(n *Neural, examples Examples) float64 {
+	predictions, responses := make([][]float64, len(examples)), make([][]float64, len(examples))
+	for i, e := range examples {
+		predictions[i] = toFloat64(n.output(e.Input))
+		responses[i] = toFloat64(e.Response)
+	}
+	return deep.GetLoss(n.Config.Loss).F(predictions, responses)
+}
+
+func toFloat64(xx []float32) []float64 {
+	res := make([]float64, len(xx))
+	for i, x := range xx {
+		res[i] = float64(x)
+	}
+	return res
+}
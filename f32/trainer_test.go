@@ -0,0 +1,165 @@
+package f32
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_xor(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewUniform(.25, 0),
+		Bias:       true,
+	})
+	permutations := Examples{
+		{[]float32{0, 0}, []float32{0}},
+		{[]float32{1, 0}, []float32{1}},
+		{[]float32{0, 1}, []float32{1}},
+		{[]float32{1, 1}, []float32{0}},
+	}
+
+	trainer := NewTrainer(training.NewSGD(1.0, 0.1, 1e-6, false), 0)
+	trainer.Train(n, permutations, permutations, 500)
+
+	for _, perm := range permutations {
+		assert.InEpsilon(t, n.Predict(perm.Input)[0]+1, perm.Response[0]+1, 0.2)
+	}
+	assert.True(t, Loss(n, permutations) < 0.1)
+}
+
+func Test_BatchXor(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewUniform(.25, 0),
+		Bias:       true,
+	})
+	permutations := Examples{
+		{[]float32{0, 0}, []float32{0}},
+		{[]float32{1, 0}, []float32{1}},
+		{[]float32{0, 1}, []float32{1}},
+		{[]float32{1, 1}, []float32{0}},
+	}
+
+	trainer := NewBatchTrainer(training.NewSGD(0.5, 0.1, 1e-6, false), 0, 2, 2)
+	trainer.Train(n, permutations, permutations, 1000)
+
+	for _, perm := range permutations {
+		assert.InEpsilon(t, n.Predict(perm.Input)[0]+1, perm.Response[0]+1, 0.2)
+	}
+	assert.True(t, Loss(n, permutations) < 0.1)
+}
+
+func Test_TrainerWriter(t *testing.T) {
+	rand.Seed(0)
+	n := NewNeural(&deep.Config{
+		Inputs:     1,
+		Layout:     []int{2, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeRegression,
+		Weight:     deep.NewUniform(0.5, 0),
+		Bias:       true,
+	})
+	examples := Examples{{[]float32{0}, []float32{0}}, {[]float32{1}, []float32{1}}}
+
+	var online, batch bytes.Buffer
+	trainer := NewTrainer(training.NewSGD(0.1, 0, 0, false), 2)
+	trainer.SetWriter(&online)
+	trainer.Train(n, examples, examples, 4)
+	batchTrainer := NewBatchTrainer(training.NewSGD(0.1, 0, 0, false), 2, 2, 1)
+	batchTrainer.SetWriter(&batch)
+	batchTrainer.Train(n, examples, examples, 4)
+
+	for _, out := range []string{online.String(), batch.String()} {
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 4)
+		assert.Contains(t, lines[0], "Loss (MSE)")
+		assert.True(t, strings.HasPrefix(lines[2], "2 "))
+		assert.True(t, strings.HasPrefix(lines[3], "4 "))
+	}
+}
+
+func Test_Regression(t *testing.T) {
+	rand.Seed(0)
+
+	var data training.Examples
+	for i := 0.0; i < 1; i += 0.01 {
+		data = append(data, training.Example{Input: []float64{i}, Response: []float64{math.Sin(i)}})
+	}
+	n := NewNeural(&deep.Config{
+		Inputs:     1,
+		Layout:     []int{4, 4, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeRegression,
+		Weight:     deep.NewUniform(0.5, 0),
+		Bias:       true,
+	})
+
+	trainer := NewTrainer(training.NewAdam(0.01, 0, 0, 0), 0)
+	trainer.Train(n, FromExamples(data), nil, 500)
+
+	for _, x := range []float32{0.1, 0.25, 0.5, 0.75, 0.9} {
+		assert.InEpsilon(t, math.Sin(float64(x))+1, float64(n.Predict([]float32{x})[0])+1, 0.1)
+	}
+}
+
+func Test_CheckGradients(t *testing.T) {
+	rand.Seed(0)
+	configs := []*deep.Config{
+		{Inputs: 2, Layout: []int{3, 3}, Activation: deep.ActivationTanh, Mode: deep.ModeMultiClass, Loss: deep.LossMeanSquared},
+		{Inputs: 2, Layout: []int{3, 3}, Activation: deep.ActivationSigmoid, Mode: deep.ModeMultiClass},
+		{Inputs: 3, Layout: []int{4, 2}, Activation: deep.ActivationTanh, Mode: deep.ModeMultiLabel},
+		{Inputs: 2, Layout: []int{4, 1}, Activation: deep.ActivationTanh, Mode: deep.ModeRegression},
+	}
+	for _, c := range configs {
+		c.Weight, c.Bias = deep.NewNormal(1, 0), true
+		n := NewNeural(c)
+		var examples training.Examples
+		for i := 0; i < 4; i++ {
+			input := make([]float64, c.Inputs)
+			for j := range input {
+				input[j] = rand.Float64()*2 - 1
+			}
+			response := make([]float64, c.Layout[len(c.Layout)-1])
+			response[rand.Intn(len(response))] = 1
+			examples = append(examples, training.Example{Input: input, Response: response})
+		}
+
+		// The gradients accumulated by the batch trainer, scaled as the
+		// analytic gradients of CheckGradients
+		w := newWorker(n)
+		for _, e := range FromExamples(examples) {
+			w.accumulate(e)
+		}
+		scale := 1 / float32(len(examples))
+		if c.Loss == deep.LossMeanSquared {
+			scale *= 2 / float32(n.Layers[len(n.Layers)-1].Size())
+		}
+
+		g := training.CheckGradients(n.Float64(), examples, 0)
+		var idx int
+		for i := range g.Numeric {
+			for j := range g.Numeric[i] {
+				for k, num := range g.Numeric[i][j] {
+					assert.InDelta(t, num, float64(w.gradients[idx]*scale), 1e-4, "mode %d layer %d neuron %d input %d", c.Mode, i, j, k)
+					idx++
+				}
+			}
+		}
+		assert.Equal(t, n.NumWeights(), idx)
+	}
+}
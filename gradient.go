@@ -0,0 +1,94 @@
+package deep
+
+import "fmt"
+
+// InputGradient returns the gradient of output outputIndex with respect to
+// the input, as taken by Forward, i.e. without preprocessing or calibration
+func (n *Neural) InputGradient(input []float64, outputIndex int) ([]float64, error) {
+	if err := n.Forward(input); err != nil {
+		return nil, err
+	}
+	outputs := len(n.Layers[len(n.Layers)-1].Neurons)
+	if outputIndex < 0 || outputIndex >= outputs {
+		return nil, fmt.Errorf("Invalid output index - expected: [0, %d) got: %d", outputs, outputIndex)
+	}
+	return n.inputGradient(newDeltas(n), outputIndex), nil
+}
+
+// Jacobian returns the derivatives of every output with respect to every
+// input, indexed by output then input
+func (n *Neural) Jacobian(input []float64) ([][]float64, error) {
+	if err := n.Forward(input); err != nil {
+		return nil, err
+	}
+	deltas := newDeltas(n)
+	res := make([][]float64, len(n.Layers[len(n.Layers)-1].Neurons))
+	for k := range res {
+		res[k] = n.inputGradient(deltas, k)
+	}
+	return res, nil
+}
+
+// Backpropagate propagates the deltas of the output layer, deltas[len-1],
+// through the hidden layers following the last forward pass. deltas holds
+// one slice per layer, sized as the layer.
+func (n *Neural) Backpropagate(deltas [][]float64) {
+	for i := len(n.Layers) - 2; i >= 0; i-- {
+		for j, neuron := range n.Layers[i].Neurons {
+			var sum float64
+			for k, s := range neuron.Out {
+				sum += s.Weight * deltas[i+1][k]
+			}
+			deltas[i][j] = neuron.DActivate(neuron.Value) * sum
+		}
+	}
+}
+
+// SoftmaxDeltas converts the derivatives g of a function of the outputs of
+// a softmax layer into deltas, derivatives with respect to its inputs:
+// δ_j = y_j (g_j - Σ_k y_k g_k)
+func (l *Layer) SoftmaxDeltas(g []float64) {
+	var dot float64
+	for k, neuron := range l.Neurons {
+		dot += neuron.Value * g[k]
+	}
+	for j, neuron := range l.Neurons {
+		g[j] = neuron.Value * (g[j] - dot)
+	}
+}
+
+func newDeltas(n *Neural) [][]float64 {
+	deltas := make([][]float64, len(n.Layers))
+	for i, l := range n.Layers {
+		deltas[i] = make([]float64, len(l.Neurons))
+	}
+	return deltas
+}
+
+// inputGradient backpropagates the derivative of output k through the
+// last forward pass
+func (n *Neural) inputGradient(deltas [][]float64, k int) []float64 {
+	last := n.Layers[len(n.Layers)-1]
+	out := deltas[len(deltas)-1]
+	for j, neuron := range last.Neurons {
+		out[j] = 0
+		if j == k {
+			out[j] = 1
+		}
+		if last.A != ActivationSoftmax {
+			out[j] *= neuron.DActivate(neuron.Value)
+		}
+	}
+	if last.A == ActivationSoftmax {
+		last.SoftmaxDeltas(out)
+	}
+	n.Backpropagate(deltas)
+
+	res := make([]float64, n.Config.Inputs)
+	for j, neuron := range n.Layers[0].Neurons {
+		for i := range res {
+			res[i] += neuron.In[i].Weight * deltas[0][j]
+		}
+	}
+	return res
+}
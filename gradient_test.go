@@ -0,0 +1,64 @@
+package deep
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// finiteDifference approximates the derivative of output k with respect
+// to each input by central differences
+func finiteDifference(n *Neural, input []float64, k int) []float64 {
+	res := make([]float64, len(input))
+	x := append([]float64{}, input...)
+	h := 1e-6
+	for i := range x {
+		x[i] = input[i] + h
+		up := n.Predict(x)[k]
+		x[i] = input[i] - h
+		down := n.Predict(x)[k]
+		x[i] = input[i]
+		res[i] = (up - down) / (2 * h)
+	}
+	return res
+}
+
+func Test_InputGradient(t *testing.T) {
+	rand.Seed(0)
+	modes := []Mode{ModeDefault, ModeMultiClass, ModeRegression, ModeBinary, ModeMultiLabel}
+	activations := []ActivationType{ActivationSigmoid, ActivationTanh, ActivationReLU, ActivationLinear}
+	for _, mode := range modes {
+		for _, act := range activations {
+			n := NewNeural(&Config{
+				Inputs:     4,
+				Layout:     []int{5, 4, 3},
+				Activation: act,
+				Mode:       mode,
+				Weight:     NewNormal(1, 0),
+				Bias:       true,
+			})
+			input := []float64{0.4, -0.3, 0.8, 0.1}
+			jacobian, err := n.Jacobian(input)
+			assert.NoError(t, err)
+			assert.Len(t, jacobian, 3)
+			for k := 0; k < 3; k++ {
+				g, err := n.InputGradient(input, k)
+				assert.NoError(t, err)
+				expected := finiteDifference(n, input, k)
+				assert.InDeltaSlice(t, expected, g, 1e-6, "mode %d activation %d output %d", mode, act, k)
+				assert.Equal(t, g, jacobian[k])
+			}
+		}
+	}
+}
+
+func Test_InputGradientErrors(t *testing.T) {
+	n := NewNeural(&Config{Inputs: 2, Layout: []int{2}, Mode: ModeBinary})
+	_, err := n.InputGradient([]float64{1}, 0)
+	assert.Error(t, err)
+	_, err = n.InputGradient([]float64{1, 2}, 2)
+	assert.Error(t, err)
+	_, err = n.Jacobian([]float64{1, 2, 3})
+	assert.Error(t, err)
+}
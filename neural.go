@@ -9,6 +9,10 @@ type Neural struct {
 	Layers []*Layer
 	Biases [][]*Synapse
 	Config *Config
+	// Preprocessing is applied to raw features by Predict, not by Forward
+	Preprocessing Pipeline
+	// Calibrator is applied to outputs by Predict
+	Calibrator Calibrator
 }
 
 // Config defines the network topology, activations, losses etc
@@ -43,9 +47,9 @@ func NewNeural(c *Config) *Neural {
 	}
 	if c.Loss == LossNone {
 		switch c.Mode {
-		case ModeMultiClass, ModeMultiLabel:
+		case ModeMultiClass:
 			c.Loss = LossCrossEntropy
-		case ModeBinary:
+		case ModeBinary, ModeMultiLabel:
 			c.Loss = LossBinaryCrossEntropy
 		default:
 			c.Loss = LossMeanSquared
@@ -121,8 +125,12 @@ func (n *Neural) Forward(input []float64) error {
 	return nil
 }
 
-// Predict computes a forward pass and returns a prediction
+// Predict preprocesses input, computes a forward pass and returns a
+// prediction, calibrated if n has a calibrator
 func (n *Neural) Predict(input []float64) []float64 {
+	if n.Preprocessing != nil {
+		input = n.Preprocessing.Transform(input)
+	}
 	n.Forward(input)
 
 	outLayer := n.Layers[len(n.Layers)-1]
@@ -130,6 +138,9 @@ func (n *Neural) Predict(input []float64) []float64 {
 	for i, neuron := range outLayer.Neurons {
 		out[i] = neuron.Value
 	}
+	if n.Calibrator != nil {
+		return n.Calibrator.Calibrate(out)
+	}
 	return out
 }
 
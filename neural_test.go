@@ -86,3 +86,16 @@ func Test_NumWeights(t *testing.T) {
 	n := NewNeural(&Config{Layout: []int{5, 5, 3}})
 	assert.Equal(t, n.NumWeights(), 5*5+3*5)
 }
+
+func Test_DefaultLoss(t *testing.T) {
+	for mode, loss := range map[Mode]LossType{
+		ModeDefault:    LossMeanSquared,
+		ModeRegression: LossMeanSquared,
+		ModeMultiClass: LossCrossEntropy,
+		ModeBinary:     LossBinaryCrossEntropy,
+		ModeMultiLabel: LossBinaryCrossEntropy,
+	} {
+		n := NewNeural(&Config{Inputs: 2, Layout: []int{3, 2}, Mode: mode})
+		assert.Equal(t, loss, n.Config.Loss, "mode %d", mode)
+	}
+}
@@ -0,0 +1,149 @@
+package onnx
+
+import (
+	"fmt"
+	"io"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+const (
+	inputName  = "input"
+	outputName = "output"
+)
+
+// Marshal encodes n as an ONNX model. Networks with preprocessing or a
+// calibrator attached are rejected, as the model would not reproduce
+// their predictions.
+func Marshal(n *deep.Neural) ([]byte, error) {
+	if len(n.Preprocessing) > 0 || n.Calibrator != nil {
+		return nil, fmt.Errorf("onnx: cannot export preprocessing or calibration")
+	}
+	m, err := toModel(n)
+	if err != nil {
+		return nil, err
+	}
+	var e encoder
+	m.marshal(&e)
+	return e.buf, nil
+}
+
+// Export writes n to w as an ONNX model
+func Export(w io.Writer, n *deep.Neural) error {
+	b, err := Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// toModel describes n as a graph of one Gemm node per layer, followed by
+// the layer activation. Weights are stored in double precision so that
+// predictions are reproduced exactly.
+func toModel(n *deep.Neural) (*model, error) {
+	g := &graph{
+		name: "go-deep",
+		inputs: []*valueInfo{{
+			name:     inputName,
+			elemType: typeDouble,
+			shape:    []int64{-1, int64(n.Config.Inputs)},
+		}},
+	}
+
+	prev, fanIn := inputName, n.Config.Inputs
+	for i, l := range n.Layers {
+		w, b := layerWeights(l, fanIn)
+
+		wName := fmt.Sprintf("W%d", i)
+		g.initializers = append(g.initializers, &tensor{
+			name:     wName,
+			dims:     []int64{int64(len(l.Neurons)), int64(fanIn)},
+			dataType: typeDouble,
+			data:     w,
+		})
+		gemm := &node{
+			name:       fmt.Sprintf("gemm%d", i),
+			opType:     "Gemm",
+			inputs:     []string{prev, wName},
+			outputs:    []string{fmt.Sprintf("z%d", i)},
+			attributes: []*attribute{{name: "transB", typ: attrInt, i: 1}},
+		}
+		if b != nil {
+			bName := fmt.Sprintf("B%d", i)
+			g.initializers = append(g.initializers, &tensor{
+				name:     bName,
+				dims:     []int64{int64(len(l.Neurons))},
+				dataType: typeDouble,
+				data:     b,
+			})
+			gemm.inputs = append(gemm.inputs, bName)
+		}
+		g.nodes = append(g.nodes, gemm)
+		prev = gemm.outputs[0]
+
+		act, err := activationNode(l.A, i, prev)
+		if err != nil {
+			return nil, err
+		}
+		if act != nil {
+			g.nodes = append(g.nodes, act)
+			prev = act.outputs[0]
+		}
+		fanIn = len(l.Neurons)
+	}
+	g.nodes[len(g.nodes)-1].outputs[0] = outputName
+
+	g.outputs = []*valueInfo{{
+		name:     outputName,
+		elemType: typeDouble,
+		shape:    []int64{-1, int64(fanIn)},
+	}}
+
+	return &model{
+		irVersion: irVersion,
+		producer:  "go-deep",
+		opset:     opsetVersion,
+		graph:     g,
+	}, nil
+}
+
+// layerWeights returns the row-major [neurons x fanIn] weight matrix of l,
+// and its bias vector if l has bias synapses
+func layerWeights(l *deep.Layer, fanIn int) (w, b []float64) {
+	w = make([]float64, 0, len(l.Neurons)*fanIn)
+	for _, n := range l.Neurons {
+		for _, s := range n.In {
+			if s.IsBias {
+				b = append(b, s.Weight)
+				continue
+			}
+			w = append(w, s.Weight)
+		}
+	}
+	return w, b
+}
+
+func activationNode(a deep.ActivationType, layer int, input string) (*node, error) {
+	n := &node{
+		name:    fmt.Sprintf("act%d", layer),
+		inputs:  []string{input},
+		outputs: []string{fmt.Sprintf("a%d", layer)},
+	}
+	switch a {
+	case deep.ActivationSigmoid:
+		n.opType = "Sigmoid"
+	case deep.ActivationTanh:
+		n.opType = "Tanh"
+	case deep.ActivationReLU:
+		n.opType = "Relu"
+	case deep.ActivationSoftmax:
+		n.opType = "Softmax"
+		n.attributes = []*attribute{{name: "axis", typ: attrInt, i: 1}}
+	case deep.ActivationLinear:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("onnx: layer %d: unsupported activation %d", layer, a)
+	}
+	return n, nil
+}
@@ -0,0 +1,399 @@
+package onnx
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Unmarshal restores a network from an ONNX model. The graph must be a
+// single chain of dense layers, expressed either as Gemm or as MatMul
+// optionally followed by Add, each followed by at most one activation among
+// Sigmoid, Tanh, Relu and Softmax (output layer only). Identity and Dropout
+// nodes are ignored. Hidden layers must share an activation, and the output
+// activation determines Config.Mode.
+func Unmarshal(b []byte) (*deep.Neural, error) {
+	m, err := unmarshalModel(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOperators(m.graph); err != nil {
+		return nil, err
+	}
+	layers, err := readLayers(m.graph)
+	if err != nil {
+		return nil, err
+	}
+	return toNeural(layers)
+}
+
+// Import reads an ONNX model from r and restores the network
+func Import(r io.Reader) (*deep.Neural, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(b)
+}
+
+var activations = map[string]deep.ActivationType{
+	"Sigmoid": deep.ActivationSigmoid,
+	"Tanh":    deep.ActivationTanh,
+	"Relu":    deep.ActivationReLU,
+	"Softmax": deep.ActivationSoftmax,
+}
+
+var passthrough = map[string]bool{
+	"Identity": true,
+	"Dropout":  true,
+}
+
+// checkOperators reports every operator in g that cannot be imported
+func checkOperators(g *graph) error {
+	var unsupported []string
+	seen := make(map[string]bool)
+	for _, n := range g.nodes {
+		op := n.opType
+		if _, ok := activations[op]; ok || passthrough[op] || op == "Gemm" || op == "MatMul" || op == "Add" || seen[op] {
+			continue
+		}
+		seen[op] = true
+		unsupported = append(unsupported, op)
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("onnx: unsupported operators: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// layer is a dense layer read from a graph
+type layer struct {
+	// weights is a [neurons][fanIn] matrix
+	weights    [][]float64
+	bias       []float64
+	activation deep.ActivationType
+	// complete is set once an activation has been applied
+	complete bool
+}
+
+// readLayers walks the chain of nodes starting at the graph input
+func readLayers(g *graph) ([]*layer, error) {
+	initializers := make(map[string]*tensor, len(g.initializers))
+	for _, t := range g.initializers {
+		initializers[t.name] = t
+	}
+	var current string
+	for _, in := range g.inputs {
+		if _, ok := initializers[in.name]; !ok {
+			current = in.name
+			break
+		}
+	}
+	if current == "" {
+		return nil, fmt.Errorf("onnx: graph has no input")
+	}
+
+	var layers []*layer
+	last := func() *layer {
+		if len(layers) == 0 || layers[len(layers)-1].complete {
+			return nil
+		}
+		return layers[len(layers)-1]
+	}
+	for _, n := range g.nodes {
+		data, others := splitInputs(n, current)
+		if data < 0 || len(n.outputs) == 0 {
+			return nil, fmt.Errorf("onnx: node %q (%s) is not part of a feed-forward chain", n.name, n.opType)
+		}
+		switch n.opType {
+		case "Gemm":
+			if data != 0 {
+				return nil, fmt.Errorf("onnx: node %q: Gemm must multiply the input by a weight matrix", n.name)
+			}
+			l, err := gemmLayer(n, initializers)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, l)
+		case "MatMul":
+			if data != 0 || len(others) != 1 {
+				return nil, fmt.Errorf("onnx: node %q: MatMul must multiply the input by an [inputs x outputs] weight matrix", n.name)
+			}
+			l, err := matMulLayer(n, others[0], initializers)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, l)
+		case "Add":
+			l := last()
+			if l == nil || len(others) != 1 {
+				return nil, fmt.Errorf("onnx: node %q (Add) must add a bias to a dense layer", n.name)
+			}
+			if err := addBias(n, l, others[0], initializers); err != nil {
+				return nil, err
+			}
+		case "Identity", "Dropout":
+		default:
+			l := last()
+			if l == nil {
+				return nil, fmt.Errorf("onnx: node %q (%s) must follow a dense layer", n.name, n.opType)
+			}
+			if act := activations[n.opType]; act == deep.ActivationSoftmax {
+				if axis := attributeInt(n, "axis", -1); axis != 1 && axis != -1 {
+					return nil, fmt.Errorf("onnx: node %q: softmax over axis %d is not supported", n.name, axis)
+				}
+			}
+			l.activation = activations[n.opType]
+			l.complete = true
+		}
+		current = n.outputs[0]
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("onnx: graph contains no dense layers")
+	}
+	return layers, nil
+}
+
+// splitInputs returns the index of the input carrying the data flowing
+// through the chain, and the remaining non-empty inputs
+func splitInputs(n *node, current string) (data int, others []string) {
+	data = -1
+	for i, in := range n.inputs {
+		switch {
+		case in == current && data < 0:
+			data = i
+		case in != "":
+			others = append(others, in)
+		}
+	}
+	return data, others
+}
+
+func gemmLayer(n *node, initializers map[string]*tensor) (*layer, error) {
+	if len(n.inputs) < 2 {
+		return nil, fmt.Errorf("onnx: node %q: Gemm requires a weight input", n.name)
+	}
+	if attributeInt(n, "transA", 0) != 0 {
+		return nil, fmt.Errorf("onnx: node %q: Gemm with transA is not supported", n.name)
+	}
+	w, err := weightTensor(n, n.inputs[1], initializers, 2)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := int(w.dims[0]), int(w.dims[1])
+	transB := attributeInt(n, "transB", 0) != 0
+	alpha := attributeFloat(n, "alpha", 1)
+
+	l := &layer{activation: deep.ActivationLinear}
+	l.weights = scale(matrix(w.data, rows, cols, !transB), alpha)
+
+	if len(n.inputs) > 2 && n.inputs[2] != "" {
+		beta := attributeFloat(n, "beta", 1)
+		b, err := biasTensor(n, n.inputs[2], initializers, len(l.weights))
+		if err != nil {
+			return nil, err
+		}
+		l.bias = make([]float64, len(b))
+		for i := range b {
+			l.bias[i] = beta * b[i]
+		}
+	}
+	return l, nil
+}
+
+func matMulLayer(n *node, weights string, initializers map[string]*tensor) (*layer, error) {
+	w, err := weightTensor(n, weights, initializers, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &layer{
+		weights:    matrix(w.data, int(w.dims[0]), int(w.dims[1]), true),
+		activation: deep.ActivationLinear,
+	}, nil
+}
+
+func addBias(n *node, l *layer, name string, initializers map[string]*tensor) error {
+	b, err := biasTensor(n, name, initializers, len(l.weights))
+	if err != nil {
+		return err
+	}
+	if l.bias == nil {
+		l.bias = make([]float64, len(b))
+	}
+	for i := range b {
+		l.bias[i] += b[i]
+	}
+	return nil
+}
+
+// biasTensor returns a bias of the given size, broadcasting scalars
+func biasTensor(n *node, name string, initializers map[string]*tensor, size int) ([]float64, error) {
+	t, ok := initializers[name]
+	if !ok {
+		return nil, fmt.Errorf("onnx: node %q: input %q is not a constant initializer", n.name, name)
+	}
+	if t.dataType != typeFloat && t.dataType != typeDouble {
+		return nil, fmt.Errorf("onnx: node %q: tensor %q has unsupported data type %d", n.name, name, t.dataType)
+	}
+	switch len(t.data) {
+	case size:
+		return t.data, nil
+	case 1:
+		b := make([]float64, size)
+		for i := range b {
+			b[i] = t.data[0]
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("onnx: node %q: bias %q has %d values, expected %d", n.name, name, len(t.data), size)
+}
+
+func weightTensor(n *node, name string, initializers map[string]*tensor, rank int) (*tensor, error) {
+	t, ok := initializers[name]
+	if !ok {
+		return nil, fmt.Errorf("onnx: node %q: input %q is not a constant initializer", n.name, name)
+	}
+	if t.dataType != typeFloat && t.dataType != typeDouble {
+		return nil, fmt.Errorf("onnx: node %q: tensor %q has unsupported data type %d", n.name, name, t.dataType)
+	}
+	if len(t.dims) != rank {
+		return nil, fmt.Errorf("onnx: node %q: tensor %q has rank %d, expected %d", n.name, name, len(t.dims), rank)
+	}
+	for _, d := range t.dims {
+		if d <= 0 {
+			return nil, fmt.Errorf("onnx: node %q: tensor %q has empty dimensions %v", n.name, name, t.dims)
+		}
+	}
+	return t, nil
+}
+
+func attributeInt(n *node, name string, fallback int64) int64 {
+	for _, a := range n.attributes {
+		if a.name == name {
+			return a.i
+		}
+	}
+	return fallback
+}
+
+func attributeFloat(n *node, name string, fallback float64) float64 {
+	for _, a := range n.attributes {
+		if a.name == name {
+			return float64(a.f)
+		}
+	}
+	return fallback
+}
+
+func scale(m [][]float64, s float64) [][]float64 {
+	if s == 1 {
+		return m
+	}
+	for i := range m {
+		row := make([]float64, len(m[i]))
+		for j := range row {
+			row[j] = s * m[i][j]
+		}
+		m[i] = row
+	}
+	return m
+}
+
+// matrix reshapes row-major data to a [neurons][fanIn] matrix, transposing
+// if the data is laid out as [fanIn][neurons]
+func matrix(data []float64, rows, cols int, transpose bool) [][]float64 {
+	if !transpose {
+		m := make([][]float64, rows)
+		for i := range m {
+			m[i] = data[i*cols : (i+1)*cols]
+		}
+		return m
+	}
+	m := make([][]float64, cols)
+	for i := range m {
+		m[i] = make([]float64, rows)
+		for j := range m[i] {
+			m[i][j] = data[j*cols+i]
+		}
+	}
+	return m
+}
+
+// toNeural maps layers onto a Config and applies their weights
+func toNeural(layers []*layer) (*deep.Neural, error) {
+	c := &deep.Config{
+		Inputs: len(layers[0].weights[0]),
+		Layout: make([]int, len(layers)),
+	}
+	fanIn := c.Inputs
+	for i, l := range layers {
+		if len(l.weights) == 0 || len(l.weights[0]) != fanIn {
+			return nil, fmt.Errorf("onnx: layer %d: expected %d inputs", i, fanIn)
+		}
+		c.Layout[i] = len(l.weights)
+		c.Bias = c.Bias || l.bias != nil
+		fanIn = len(l.weights)
+	}
+
+	hidden := layers[:len(layers)-1]
+	out := layers[len(layers)-1]
+	for i, l := range hidden {
+		if l.activation == deep.ActivationSoftmax {
+			return nil, fmt.Errorf("onnx: layer %d: softmax is only supported on the output layer", i)
+		}
+		if l.activation != hidden[0].activation {
+			return nil, fmt.Errorf("onnx: layer %d: mixed hidden activations %d and %d are not supported", i, hidden[0].activation, l.activation)
+		}
+	}
+	c.Activation = out.activation
+	if len(hidden) > 0 {
+		c.Activation = hidden[0].activation
+	}
+
+	switch out.activation {
+	case deep.ActivationSoftmax:
+		c.Mode = deep.ModeMultiClass
+	case deep.ActivationSigmoid:
+		c.Mode = deep.ModeBinary
+		if c.Layout[len(c.Layout)-1] > 1 {
+			c.Mode = deep.ModeMultiLabel
+		}
+	case deep.ActivationLinear:
+		if c.Activation == deep.ActivationLinear && out.bias != nil {
+			// Linear throughout, the output bias is kept by ModeDefault
+			break
+		}
+		c.Mode = deep.ModeRegression
+		for _, b := range out.bias {
+			if b != 0 {
+				return nil, fmt.Errorf("onnx: bias on a linear output layer is not supported")
+			}
+		}
+	default:
+		if out.activation != c.Activation {
+			return nil, fmt.Errorf("onnx: output activation %d must match hidden activation %d", out.activation, c.Activation)
+		}
+	}
+
+	n := deep.NewNeural(c)
+	for i, l := range n.Layers {
+		for j, neuron := range l.Neurons {
+			for k, s := range neuron.In {
+				switch {
+				case !s.IsBias:
+					s.Weight = layers[i].weights[j][k]
+				case layers[i].bias != nil:
+					s.Weight = layers[i].bias[j]
+				default:
+					s.Weight = 0
+				}
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package onnx
+
+import (
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(g *graph) []byte {
+	var e encoder
+	(&model{irVersion: irVersion, producer: "test", opset: opsetVersion, graph: g}).marshal(&e)
+	return e.buf
+}
+
+func Test_ImportMatMulAdd(t *testing.T) {
+	g := &graph{
+		name:   "mlp",
+		inputs: []*valueInfo{{name: "x", elemType: typeFloat, shape: []int64{-1, 2}}},
+		initializers: []*tensor{
+			// [inputs x outputs]
+			{name: "w1", dims: []int64{2, 3}, dataType: typeFloat, data: []float64{0.5, -1, 0.25, 2, 0.75, -0.5}},
+			{name: "b1", dims: []int64{3}, dataType: typeFloat, data: []float64{0.1, 0.2, -0.3}},
+			{name: "w2", dims: []int64{3, 2}, dataType: typeFloat, data: []float64{1, -1, 0.5, 0.5, -0.25, 2}},
+			{name: "b2", dims: []int64{1, 2}, dataType: typeFloat, data: []float64{0, 0.5}},
+		},
+		nodes: []*node{
+			{name: "mm1", opType: "MatMul", inputs: []string{"x", "w1"}, outputs: []string{"h1"}},
+			{name: "add1", opType: "Add", inputs: []string{"b1", "h1"}, outputs: []string{"h2"}},
+			{name: "relu", opType: "Relu", inputs: []string{"h2"}, outputs: []string{"h3"}},
+			{name: "drop", opType: "Dropout", inputs: []string{"h3"}, outputs: []string{"h4", "mask"}},
+			{name: "mm2", opType: "MatMul", inputs: []string{"h4", "w2"}, outputs: []string{"h5"}},
+			{name: "add2", opType: "Add", inputs: []string{"h5", "b2"}, outputs: []string{"h6"}},
+			{name: "sm", opType: "Softmax", inputs: []string{"h6"}, outputs: []string{"y"},
+				attributes: []*attribute{{name: "axis", typ: attrInt, i: -1}}},
+		},
+		outputs: []*valueInfo{{name: "y", elemType: typeFloat, shape: []int64{-1, 2}}},
+	}
+
+	n, err := Unmarshal(encode(g))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n.Config.Inputs)
+	assert.Equal(t, []int{3, 2}, n.Config.Layout)
+	assert.Equal(t, deep.ActivationReLU, n.Config.Activation)
+	assert.Equal(t, deep.ModeMultiClass, n.Config.Mode)
+	assert.True(t, n.Config.Bias)
+
+	input := []float64{0.3, -0.7}
+	hidden := make([]float64, 3)
+	for j := range hidden {
+		hidden[j] = g.initializers[1].data[j]
+		for i := range input {
+			hidden[j] += input[i] * g.initializers[0].data[i*3+j]
+		}
+		if hidden[j] < 0 {
+			hidden[j] = 0
+		}
+	}
+	out := make([]float64, 2)
+	for j := range out {
+		out[j] = g.initializers[3].data[j]
+		for i := range hidden {
+			out[j] += hidden[i] * g.initializers[2].data[i*2+j]
+		}
+	}
+	// Biases are stored in single precision
+	expected := deep.Softmax(out)
+	for i, p := range n.Predict(input) {
+		assert.InEpsilon(t, expected[i], p, 1e-6)
+	}
+}
+
+func Test_ImportGemmAttributes(t *testing.T) {
+	g := &graph{
+		inputs: []*valueInfo{{name: "x", elemType: typeDouble, shape: []int64{-1, 2}}},
+		initializers: []*tensor{
+			// [inputs x outputs] since transB is unset
+			{name: "w", dims: []int64{2, 1}, dataType: typeDouble, data: []float64{1, 2}},
+			{name: "b", dims: []int64{1}, dataType: typeDouble, data: []float64{3}},
+		},
+		nodes: []*node{
+			{name: "gemm", opType: "Gemm", inputs: []string{"x", "w", "b"}, outputs: []string{"z"},
+				attributes: []*attribute{{name: "alpha", typ: attrFloat, f: 0.5}, {name: "beta", typ: attrFloat, f: 2}}},
+			{name: "sig", opType: "Sigmoid", inputs: []string{"z"}, outputs: []string{"y"}},
+		},
+	}
+
+	n, err := Unmarshal(encode(g))
+	assert.Nil(t, err)
+	assert.Equal(t, deep.ModeBinary, n.Config.Mode)
+	assert.InEpsilon(t, deep.Logistic(0.5*(1*1+2*2)+2*3, 1), n.Predict([]float64{1, 2})[0], 1e-12)
+}
+
+func Test_ImportErrors(t *testing.T) {
+	g := &graph{
+		inputs: []*valueInfo{{name: "x"}},
+		initializers: []*tensor{
+			{name: "w", dims: []int64{2, 2}, dataType: typeDouble, data: []float64{1, 2, 3, 4}},
+		},
+		nodes: []*node{
+			{name: "conv", opType: "Conv", inputs: []string{"x", "w"}, outputs: []string{"a"}},
+			{name: "pool", opType: "MaxPool", inputs: []string{"a"}, outputs: []string{"b"}},
+			{name: "conv2", opType: "Conv", inputs: []string{"b", "w"}, outputs: []string{"c"}},
+			{name: "leaky", opType: "LeakyRelu", inputs: []string{"c"}, outputs: []string{"d"}},
+		},
+	}
+	_, err := Unmarshal(encode(g))
+	assert.EqualError(t, err, "onnx: unsupported operators: Conv, LeakyRelu, MaxPool")
+
+	g.nodes = []*node{
+		{name: "mm", opType: "MatMul", inputs: []string{"x", "w"}, outputs: []string{"a"}},
+		{name: "sm", opType: "Softmax", inputs: []string{"a"}, outputs: []string{"b"}},
+		{name: "mm2", opType: "MatMul", inputs: []string{"b", "w"}, outputs: []string{"c"}},
+	}
+	_, err = Unmarshal(encode(g))
+	assert.EqualError(t, err, "onnx: layer 0: softmax is only supported on the output layer")
+
+	g.nodes = []*node{
+		{name: "mm", opType: "MatMul", inputs: []string{"w", "x"}, outputs: []string{"a"}},
+	}
+	_, err = Unmarshal(encode(g))
+	assert.Error(t, err)
+
+	g.nodes = []*node{
+		{name: "gemm", opType: "Gemm", inputs: []string{"x", "e"}, outputs: []string{"a"}},
+	}
+	g.initializers = append(g.initializers, &tensor{name: "e", dims: []int64{0, 2}, dataType: typeDouble})
+	_, err = Unmarshal(encode(g))
+	assert.EqualError(t, err, `onnx: node "gemm": tensor "e" has empty dimensions [0 2]`)
+
+}
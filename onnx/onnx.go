@@ -0,0 +1,503 @@
+// Package onnx converts go-deep networks to and from the ONNX model format.
+//
+// Only the subset of the ONNX protobuf schema needed to describe
+// feed-forward networks is implemented, using a hand-written wire encoder.
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ONNX tensor element types
+const (
+	typeFloat  = 1
+	typeDouble = 11
+)
+
+// ONNX attribute types
+const (
+	attrFloat = 1
+	attrInt   = 2
+)
+
+const (
+	irVersion    = 7
+	opsetVersion = 13
+)
+
+type model struct {
+	irVersion int64
+	producer  string
+	opset     int64
+	graph     *graph
+}
+
+type graph struct {
+	name         string
+	nodes        []*node
+	initializers []*tensor
+	inputs       []*valueInfo
+	outputs      []*valueInfo
+}
+
+type node struct {
+	name       string
+	opType     string
+	inputs     []string
+	outputs    []string
+	attributes []*attribute
+}
+
+type attribute struct {
+	name string
+	typ  int64
+	f    float32
+	i    int64
+}
+
+type tensor struct {
+	name     string
+	dims     []int64
+	dataType int64
+	data     []float64
+}
+
+type valueInfo struct {
+	name     string
+	elemType int64
+	// shape holds fixed dimensions, or -1 for a symbolic dimension
+	shape []int64
+}
+
+func (m *model) marshal(e *encoder) {
+	e.int64(1, m.irVersion)
+	e.string(2, m.producer)
+	e.message(7, m.graph)
+	e.message(8, opsetID(m.opset))
+}
+
+type opsetID int64
+
+func (o opsetID) marshal(e *encoder) {
+	e.string(1, "")
+	e.int64(2, int64(o))
+}
+
+func (g *graph) marshal(e *encoder) {
+	for _, n := range g.nodes {
+		e.message(1, n)
+	}
+	e.string(2, g.name)
+	for _, t := range g.initializers {
+		e.message(5, t)
+	}
+	for _, v := range g.inputs {
+		e.message(11, v)
+	}
+	for _, v := range g.outputs {
+		e.message(12, v)
+	}
+}
+
+func (n *node) marshal(e *encoder) {
+	for _, in := range n.inputs {
+		e.string(1, in)
+	}
+	for _, out := range n.outputs {
+		e.string(2, out)
+	}
+	e.string(3, n.name)
+	e.string(4, n.opType)
+	for _, a := range n.attributes {
+		e.message(5, a)
+	}
+}
+
+func (a *attribute) marshal(e *encoder) {
+	e.string(1, a.name)
+	switch a.typ {
+	case attrFloat:
+		e.float32(2, a.f)
+	case attrInt:
+		e.int64(3, a.i)
+	}
+	e.int64(20, a.typ)
+}
+
+func (t *tensor) marshal(e *encoder) {
+	e.packedInt64(1, t.dims)
+	e.int64(2, t.dataType)
+	e.string(8, t.name)
+	var raw []byte
+	if t.dataType == typeFloat {
+		raw = make([]byte, 4*len(t.data))
+		for i, v := range t.data {
+			binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(float32(v)))
+		}
+	} else {
+		raw = make([]byte, 8*len(t.data))
+		for i, v := range t.data {
+			binary.LittleEndian.PutUint64(raw[8*i:], math.Float64bits(v))
+		}
+	}
+	e.bytes(9, raw)
+}
+
+func (v *valueInfo) marshal(e *encoder) {
+	e.string(1, v.name)
+	e.message(2, typeProto{v})
+}
+
+// typeProto wraps the nested TypeProto/TypeProto.Tensor/TensorShapeProto messages
+type typeProto struct{ v *valueInfo }
+
+func (t typeProto) marshal(e *encoder) {
+	e.message(1, tensorType{t.v})
+}
+
+type tensorType struct{ v *valueInfo }
+
+func (t tensorType) marshal(e *encoder) {
+	e.int64(1, t.v.elemType)
+	e.message(2, shapeProto(t.v.shape))
+}
+
+type shapeProto []int64
+
+func (s shapeProto) marshal(e *encoder) {
+	for _, d := range s {
+		e.message(1, dimension(d))
+	}
+}
+
+type dimension int64
+
+func (d dimension) marshal(e *encoder) {
+	if d < 0 {
+		e.string(2, "N")
+		return
+	}
+	e.int64(1, int64(d))
+}
+
+func unmarshalModel(b []byte) (*model, error) {
+	m := &model{}
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			m.irVersion = int64(v)
+		case field == 2 && wire == wireBytes:
+			if m.producer, err = d.string(); err != nil {
+				return nil, err
+			}
+		case field == 7 && wire == wireBytes:
+			sub, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			if m.graph, err = unmarshalGraph(sub); err != nil {
+				return nil, err
+			}
+		case field == 8 && wire == wireBytes:
+			sub, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			version, err := unmarshalOpset(sub)
+			if err != nil {
+				return nil, err
+			}
+			if version > 0 {
+				m.opset = version
+			}
+		default:
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if m.graph == nil {
+		return nil, fmt.Errorf("onnx: model has no graph")
+	}
+	return m, nil
+}
+
+func unmarshalOpset(b []byte) (int64, error) {
+	var version int64
+	var domain string
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case field == 1 && wire == wireBytes:
+			if domain, err = d.string(); err != nil {
+				return 0, err
+			}
+		case field == 2 && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return 0, err
+			}
+			version = int64(v)
+		default:
+			if err := d.skip(wire); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if domain != "" && domain != "ai.onnx" {
+		return 0, nil
+	}
+	return version, nil
+}
+
+func unmarshalGraph(b []byte) (*graph, error) {
+	g := &graph{}
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if wire != wireBytes {
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sub, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			n, err := unmarshalNode(sub)
+			if err != nil {
+				return nil, err
+			}
+			g.nodes = append(g.nodes, n)
+		case 2:
+			g.name = string(sub)
+		case 5:
+			t, err := unmarshalTensor(sub)
+			if err != nil {
+				return nil, err
+			}
+			g.initializers = append(g.initializers, t)
+		case 11, 12:
+			v, err := unmarshalValueInfo(sub)
+			if err != nil {
+				return nil, err
+			}
+			if field == 11 {
+				g.inputs = append(g.inputs, v)
+			} else {
+				g.outputs = append(g.outputs, v)
+			}
+		}
+	}
+	return g, nil
+}
+
+func unmarshalNode(b []byte) (*node, error) {
+	n := &node{}
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if wire != wireBytes {
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sub, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			n.inputs = append(n.inputs, string(sub))
+		case 2:
+			n.outputs = append(n.outputs, string(sub))
+		case 3:
+			n.name = string(sub)
+		case 4:
+			n.opType = string(sub)
+		case 5:
+			a, err := unmarshalAttribute(sub)
+			if err != nil {
+				return nil, err
+			}
+			n.attributes = append(n.attributes, a)
+		}
+	}
+	return n, nil
+}
+
+func unmarshalAttribute(b []byte) (*attribute, error) {
+	a := &attribute{}
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wire == wireBytes:
+			if a.name, err = d.string(); err != nil {
+				return nil, err
+			}
+		case field == 2 && wire == wireFixed32:
+			v, err := d.fixed32()
+			if err != nil {
+				return nil, err
+			}
+			a.f = math.Float32frombits(v)
+		case field == 3 && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			a.i = int64(v)
+		case field == 20 && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			a.typ = int64(v)
+		default:
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+func unmarshalTensor(b []byte) (*tensor, error) {
+	t := &tensor{}
+	var raw []byte
+	var floats []float32
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1:
+			if t.dims, err = d.int64s(wire, t.dims); err != nil {
+				return nil, err
+			}
+		case field == 2 && wire == wireVarint:
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			t.dataType = int64(v)
+		case field == 4:
+			if floats, err = d.float32s(wire, floats); err != nil {
+				return nil, err
+			}
+		case field == 8 && wire == wireBytes:
+			if t.name, err = d.string(); err != nil {
+				return nil, err
+			}
+		case field == 9 && wire == wireBytes:
+			if raw, err = d.bytes(); err != nil {
+				return nil, err
+			}
+		case field == 10:
+			if t.data, err = d.float64s(wire, t.data); err != nil {
+				return nil, err
+			}
+		default:
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch t.dataType {
+	case typeDouble:
+		if raw != nil {
+			if len(raw)%8 != 0 {
+				return nil, fmt.Errorf("onnx: tensor %q: invalid raw data length %d", t.name, len(raw))
+			}
+			t.data = make([]float64, len(raw)/8)
+			for i := range t.data {
+				t.data[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))
+			}
+		}
+	case typeFloat:
+		if raw != nil {
+			if len(raw)%4 != 0 {
+				return nil, fmt.Errorf("onnx: tensor %q: invalid raw data length %d", t.name, len(raw))
+			}
+			floats = floats[:0]
+			for i := 0; i < len(raw); i += 4 {
+				floats = append(floats, math.Float32frombits(binary.LittleEndian.Uint32(raw[i:])))
+			}
+		}
+		t.data = make([]float64, len(floats))
+		for i, f := range floats {
+			t.data[i] = float64(f)
+		}
+	default:
+		// Left empty, an error is reported if the tensor is used as a weight
+		return t, nil
+	}
+
+	if size := t.size(); size != len(t.data) {
+		return nil, fmt.Errorf("onnx: tensor %q: expected %d values got %d", t.name, size, len(t.data))
+	}
+	return t, nil
+}
+
+func (t *tensor) size() int {
+	size := 1
+	for _, d := range t.dims {
+		size *= int(d)
+	}
+	return size
+}
+
+func unmarshalValueInfo(b []byte) (*valueInfo, error) {
+	v := &valueInfo{}
+	d := decoder{buf: b}
+	for !d.done() {
+		field, wire, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wire == wireBytes:
+			if v.name, err = d.string(); err != nil {
+				return nil, err
+			}
+		default:
+			// Type information is not needed, shapes are inferred from initializers
+			if err := d.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,86 @@
+package onnx
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RoundTrip(t *testing.T) {
+	rand.Seed(0)
+
+	configs := []*deep.Config{
+		{Inputs: 3, Layout: []int{4, 4, 3}, Activation: deep.ActivationReLU, Mode: deep.ModeMultiClass, Bias: true},
+		{Inputs: 2, Layout: []int{5, 1}, Activation: deep.ActivationSigmoid, Mode: deep.ModeBinary, Bias: true},
+		{Inputs: 2, Layout: []int{3, 2}, Activation: deep.ActivationTanh, Mode: deep.ModeMultiLabel, Bias: true},
+		{Inputs: 1, Layout: []int{4, 4, 1}, Activation: deep.ActivationTanh, Mode: deep.ModeRegression, Bias: true},
+		{Inputs: 2, Layout: []int{3, 2}, Activation: deep.ActivationTanh, Mode: deep.ModeDefault, Bias: false},
+	}
+
+	for _, c := range configs {
+		c.Weight = deep.NewNormal(1, 0)
+		n := deep.NewNeural(c)
+
+		var buf bytes.Buffer
+		assert.Nil(t, Export(&buf, n))
+
+		imported, err := Import(&buf)
+		assert.Nil(t, err)
+		assert.Equal(t, c.Inputs, imported.Config.Inputs)
+		assert.Equal(t, c.Layout, imported.Config.Layout)
+		assert.Equal(t, c.Mode, imported.Config.Mode)
+		assert.Equal(t, c.Activation, imported.Config.Activation)
+
+		for i := 0; i < 10; i++ {
+			input := make([]float64, c.Inputs)
+			for j := range input {
+				input[j] = rand.NormFloat64()
+			}
+			assert.Equal(t, n.Predict(input), imported.Predict(input))
+		}
+	}
+}
+
+func Test_ExportTransforms(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{3, 1}, Mode: deep.ModeBinary, Bias: true})
+	n.Preprocessing = deep.Pipeline{&deep.StandardScaler{Mean: []float64{1, 2}, Std: []float64{1, 1}}}
+	var buf bytes.Buffer
+	assert.Error(t, Export(&buf, n))
+
+	n.Preprocessing = nil
+	n.Calibrator = &deep.PlattScaling{A: 1}
+	assert.Error(t, Export(&buf, n))
+}
+
+func Test_Encoding(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{2, 2},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeMultiClass,
+		Bias:       true,
+	})
+	b, err := Marshal(n)
+	assert.Nil(t, err)
+
+	m, err := unmarshalModel(b)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(irVersion), m.irVersion)
+	assert.Equal(t, int64(opsetVersion), m.opset)
+	assert.Equal(t, "go-deep", m.producer)
+
+	var ops []string
+	for _, n := range m.graph.nodes {
+		ops = append(ops, n.opType)
+	}
+	assert.Equal(t, []string{"Gemm", "Sigmoid", "Gemm", "Softmax"}, ops)
+	assert.Len(t, m.graph.initializers, 4)
+	assert.Equal(t, inputName, m.graph.inputs[0].name)
+	assert.Equal(t, outputName, m.graph.outputs[0].name)
+
+	_, err = Unmarshal(b[:len(b)-3])
+	assert.Error(t, err)
+}
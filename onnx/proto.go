@@ -0,0 +1,221 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("onnx: truncated message")
+
+// encoder is a minimal protobuf wire format writer
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) varint(v uint64) {
+	for v >= 0x80 {
+		e.buf = append(e.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *encoder) tag(field, wire int) {
+	e.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (e *encoder) int64(field int, v int64) {
+	e.tag(field, wireVarint)
+	e.varint(uint64(v))
+}
+
+func (e *encoder) float32(field int, v float32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+	e.tag(field, wireFixed32)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.varint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(field int, s string) {
+	e.bytes(field, []byte(s))
+}
+
+func (e *encoder) message(field int, m marshaler) {
+	var sub encoder
+	m.marshal(&sub)
+	e.bytes(field, sub.buf)
+}
+
+func (e *encoder) packedInt64(field int, vs []int64) {
+	var sub encoder
+	for _, v := range vs {
+		sub.varint(uint64(v))
+	}
+	e.bytes(field, sub.buf)
+}
+
+type marshaler interface {
+	marshal(e *encoder)
+}
+
+// decoder is a minimal protobuf wire format reader
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) done() bool {
+	return d.pos >= len(d.buf)
+}
+
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.pos >= len(d.buf) {
+			return 0, errTruncated
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("onnx: varint overflow")
+}
+
+func (d *decoder) next() (field, wire int, err error) {
+	v, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 7), nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	l, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)-d.pos) < l {
+		return nil, errTruncated
+	}
+	b := d.buf[d.pos : d.pos+int(l)]
+	d.pos += int(l)
+	return b, nil
+}
+
+func (d *decoder) string() (string, error) {
+	b, err := d.bytes()
+	return string(b), err
+}
+
+func (d *decoder) fixed32() (uint32, error) {
+	if len(d.buf)-d.pos < 4 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint32(d.buf[d.pos:])
+	d.pos += 4
+	return v, nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.buf)-d.pos < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.buf[d.pos:])
+	d.pos += 8
+	return v, nil
+}
+
+func (d *decoder) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = d.varint()
+	case wireFixed64:
+		_, err = d.fixed64()
+	case wireBytes:
+		_, err = d.bytes()
+	case wireFixed32:
+		_, err = d.fixed32()
+	default:
+		err = fmt.Errorf("onnx: unsupported wire type %d", wire)
+	}
+	return err
+}
+
+// int64s reads a repeated int64 field in either packed or unpacked encoding
+func (d *decoder) int64s(wire int, dst []int64) ([]int64, error) {
+	if wire == wireVarint {
+		v, err := d.varint()
+		return append(dst, int64(v)), err
+	}
+	b, err := d.bytes()
+	if err != nil {
+		return dst, err
+	}
+	sub := decoder{buf: b}
+	for !sub.done() {
+		v, err := sub.varint()
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, int64(v))
+	}
+	return dst, nil
+}
+
+// float32s reads a repeated float field in either packed or unpacked encoding
+func (d *decoder) float32s(wire int, dst []float32) ([]float32, error) {
+	if wire == wireFixed32 {
+		v, err := d.fixed32()
+		return append(dst, math.Float32frombits(v)), err
+	}
+	b, err := d.bytes()
+	if err != nil {
+		return dst, err
+	}
+	if len(b)%4 != 0 {
+		return dst, errTruncated
+	}
+	for i := 0; i < len(b); i += 4 {
+		dst = append(dst, math.Float32frombits(binary.LittleEndian.Uint32(b[i:])))
+	}
+	return dst, nil
+}
+
+// float64s reads a repeated double field in either packed or unpacked encoding
+func (d *decoder) float64s(wire int, dst []float64) ([]float64, error) {
+	if wire == wireFixed64 {
+		v, err := d.fixed64()
+		return append(dst, math.Float64frombits(v)), err
+	}
+	b, err := d.bytes()
+	if err != nil {
+		return dst, err
+	}
+	if len(b)%8 != 0 {
+		return dst, errTruncated
+	}
+	for i := 0; i < len(b); i += 8 {
+		dst = append(dst, math.Float64frombits(binary.LittleEndian.Uint64(b[i:])))
+	}
+	return dst, nil
+}
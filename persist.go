@@ -6,8 +6,48 @@ import (
 
 // Dump is a neural network dump
 type Dump struct {
-	Config  *Config
-	Weights [][][]float64
+	Config        *Config
+	Weights       [][][]float64
+	Preprocessing Pipeline   `json:",omitempty"`
+	Calibrator    Calibrator `json:"-"`
+}
+
+// dump has the fields of Dump without its JSON methods
+type dump Dump
+
+type dumpJSON struct {
+	dump
+	Calibrator *typedJSON `json:",omitempty"`
+}
+
+// MarshalJSON marshals the dump, recording the type of its calibrator
+func (d Dump) MarshalJSON() ([]byte, error) {
+	j := dumpJSON{dump: dump(d)}
+	if d.Calibrator != nil {
+		c, err := marshalCalibrator(d.Calibrator)
+		if err != nil {
+			return nil, err
+		}
+		j.Calibrator = c
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON restores a dump marshaled by MarshalJSON
+func (d *Dump) UnmarshalJSON(data []byte) error {
+	var j dumpJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*d = Dump(j.dump)
+	if j.Calibrator != nil {
+		c, err := unmarshalCalibrator(j.Calibrator)
+		if err != nil {
+			return err
+		}
+		d.Calibrator = c
+	}
+	return nil
 }
 
 // ApplyWeights sets the weights from a three-dimensional slice
@@ -39,8 +79,10 @@ func (n Neural) Weights() [][][]float64 {
 // Dump generates a network dump
 func (n Neural) Dump() *Dump {
 	return &Dump{
-		Config:  n.Config,
-		Weights: n.Weights(),
+		Config:        n.Config,
+		Weights:       n.Weights(),
+		Preprocessing: n.Preprocessing,
+		Calibrator:    n.Calibrator,
 	}
 }
 
@@ -48,6 +90,8 @@ func (n Neural) Dump() *Dump {
 func FromDump(dump *Dump) *Neural {
 	n := NewNeural(dump.Config)
 	n.ApplyWeights(dump.Weights)
+	n.Preprocessing = dump.Preprocessing
+	n.Calibrator = dump.Calibrator
 
 	return n
 }
@@ -55,3 +55,28 @@ func Test_Marshal(t *testing.T) {
 	assert.Equal(t, n.String(), new.String())
 	assert.Equal(t, n.Predict([]float64{0}), new.Predict([]float64{0}))
 }
+
+func Test_MarshalPreprocessing(t *testing.T) {
+	rand.Seed(0)
+
+	n := NewNeural(&Config{
+		Inputs:     2,
+		Layout:     []int{3, 1},
+		Activation: ActivationSigmoid,
+		Weight:     NewUniform(0.5, 0),
+		Bias:       true,
+	})
+	n.Preprocessing = Pipeline{NewStandardScaler()}
+	n.Preprocessing.Fit([][]float64{{1, 100}, {3, 300}})
+
+	dump, err := n.Marshal()
+	assert.Nil(t, err)
+	new, err := Unmarshal(dump)
+	assert.Nil(t, err)
+
+	// Predict takes raw features, Forward takes network inputs
+	raw := []float64{3, 100}
+	assert.Equal(t, n.Predict(raw), new.Predict(raw))
+	new.Forward(n.Preprocessing.Transform(raw))
+	assert.Equal(t, n.Predict(raw)[0], new.Layers[1].Neurons[0].Value)
+}
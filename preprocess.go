@@ -0,0 +1,338 @@
+package deep
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Preprocessor is a column-wise transformation of raw features, fitted on
+// training data and applied to every input thereafter
+type Preprocessor interface {
+	// Fit learns the transformation from rows of raw features
+	Fit(rows [][]float64)
+	// Transform returns a transformed copy of a row
+	Transform(x []float64) []float64
+}
+
+// StandardScaler shifts columns to μ=0 σ=1
+type StandardScaler struct {
+	// Columns to scale, all if empty
+	Columns []int
+	Mean    []float64
+	Std     []float64
+}
+
+// NewStandardScaler returns a scaler of columns, or of all columns if none
+// are given
+func NewStandardScaler(columns ...int) *StandardScaler {
+	return &StandardScaler{Columns: columns}
+}
+
+// Fit computes the mean and standard deviation of each column
+func (s *StandardScaler) Fit(rows [][]float64) {
+	s.Mean, s.Std = fitColumns(rows, s.Columns, func(xx []float64) (float64, float64) {
+		return Mean(xx), StandardDeviation(xx)
+	})
+}
+
+// Transform standardizes a row
+func (s *StandardScaler) Transform(x []float64) []float64 {
+	return affine(x, s.Mean, s.Std)
+}
+
+// MinMaxScaler scales columns to (0,1)
+type MinMaxScaler struct {
+	// Columns to scale, all if empty
+	Columns []int
+	Min     []float64
+	Max     []float64
+}
+
+// NewMinMaxScaler returns a scaler of columns, or of all columns if none
+// are given
+func NewMinMaxScaler(columns ...int) *MinMaxScaler {
+	return &MinMaxScaler{Columns: columns}
+}
+
+// Fit computes the range of each column
+func (s *MinMaxScaler) Fit(rows [][]float64) {
+	s.Min, s.Max = fitColumns(rows, s.Columns, func(xx []float64) (float64, float64) {
+		return Min(xx), Max(xx)
+	})
+}
+
+// Transform normalizes a row
+func (s *MinMaxScaler) Transform(x []float64) []float64 {
+	scale := make([]float64, len(s.Min))
+	for i := range scale {
+		scale[i] = s.Max[i] - s.Min[i]
+	}
+	return affine(x, s.Min, scale)
+}
+
+// RobustScaler centers columns on the median and scales by the
+// interquartile range, limiting the influence of outliers
+type RobustScaler struct {
+	// Columns to scale, all if empty
+	Columns []int
+	Median  []float64
+	IQR     []float64
+}
+
+// NewRobustScaler returns a scaler of columns, or of all columns if none
+// are given
+func NewRobustScaler(columns ...int) *RobustScaler {
+	return &RobustScaler{Columns: columns}
+}
+
+// Fit computes the median and interquartile range of each column
+func (s *RobustScaler) Fit(rows [][]float64) {
+	s.Median, s.IQR = fitColumns(rows, s.Columns, func(xx []float64) (float64, float64) {
+		sort.Float64s(xx)
+		return quantile(xx, 0.5), quantile(xx, 0.75) - quantile(xx, 0.25)
+	})
+}
+
+// Transform scales a row
+func (s *RobustScaler) Transform(x []float64) []float64 {
+	return affine(x, s.Median, s.IQR)
+}
+
+// OneHotEncoder replaces categorical columns by one indicator per
+// category. Categories not seen during Fit are encoded as all zeros.
+type OneHotEncoder struct {
+	Columns []int
+	// Categories holds the sorted categories of each column
+	Categories [][]float64
+}
+
+// NewOneHotEncoder returns an encoder of columns
+func NewOneHotEncoder(columns ...int) *OneHotEncoder {
+	return &OneHotEncoder{Columns: columns}
+}
+
+// Fit collects the categories of each column
+func (e *OneHotEncoder) Fit(rows [][]float64) {
+	e.Categories = fitCategories(rows, e.Columns)
+}
+
+// Transform encodes a row, the indicators of a column taking its place
+func (e *OneHotEncoder) Transform(x []float64) []float64 {
+	encoded := make(map[int][]float64, len(e.Columns))
+	for i, c := range e.Columns {
+		encoded[c] = e.Categories[i]
+	}
+	res := make([]float64, 0, len(x))
+	for i, v := range x {
+		categories, ok := encoded[i]
+		if !ok {
+			res = append(res, v)
+			continue
+		}
+		for _, c := range categories {
+			if c == v {
+				res = append(res, 1)
+			} else {
+				res = append(res, 0)
+			}
+		}
+	}
+	return res
+}
+
+// OrdinalEncoder replaces categorical columns by the index of their
+// category. Categories not seen during Fit are encoded as -1.
+type OrdinalEncoder struct {
+	Columns []int
+	// Categories holds the sorted categories of each column
+	Categories [][]float64
+}
+
+// NewOrdinalEncoder returns an encoder of columns
+func NewOrdinalEncoder(columns ...int) *OrdinalEncoder {
+	return &OrdinalEncoder{Columns: columns}
+}
+
+// Fit collects the categories of each column
+func (e *OrdinalEncoder) Fit(rows [][]float64) {
+	e.Categories = fitCategories(rows, e.Columns)
+}
+
+// Transform encodes a row
+func (e *OrdinalEncoder) Transform(x []float64) []float64 {
+	res := append([]float64{}, x...)
+	for i, c := range e.Columns {
+		categories := e.Categories[i]
+		j := sort.SearchFloat64s(categories, x[c])
+		if j == len(categories) || categories[j] != x[c] {
+			j = -1
+		}
+		res[c] = float64(j)
+	}
+	return res
+}
+
+// Pipeline chains preprocessors, each fitted on the output of the previous
+type Pipeline []Preprocessor
+
+// Fit fits each preprocessor in turn
+func (p Pipeline) Fit(rows [][]float64) {
+	for i, pre := range p {
+		pre.Fit(rows)
+		if i == len(p)-1 {
+			break
+		}
+		transformed := make([][]float64, len(rows))
+		for j, row := range rows {
+			transformed[j] = pre.Transform(row)
+		}
+		rows = transformed
+	}
+}
+
+// Transform applies every preprocessor
+func (p Pipeline) Transform(x []float64) []float64 {
+	for _, pre := range p {
+		x = pre.Transform(x)
+	}
+	return x
+}
+
+// typedJSON is a marshaled value of one of several types implementing an
+// interface
+type typedJSON struct {
+	Type   string
+	Params json.RawMessage
+}
+
+var preprocessors = map[string]func() Preprocessor{
+	"standard": func() Preprocessor { return &StandardScaler{} },
+	"minmax":   func() Preprocessor { return &MinMaxScaler{} },
+	"robust":   func() Preprocessor { return &RobustScaler{} },
+	"onehot":   func() Preprocessor { return &OneHotEncoder{} },
+	"ordinal":  func() Preprocessor { return &OrdinalEncoder{} },
+}
+
+func preprocessorType(p Preprocessor) (string, error) {
+	switch p.(type) {
+	case *StandardScaler:
+		return "standard", nil
+	case *MinMaxScaler:
+		return "minmax", nil
+	case *RobustScaler:
+		return "robust", nil
+	case *OneHotEncoder:
+		return "onehot", nil
+	case *OrdinalEncoder:
+		return "ordinal", nil
+	}
+	return "", fmt.Errorf("cannot marshal preprocessor %T", p)
+}
+
+// MarshalJSON marshals the preprocessors along with their types
+func (p Pipeline) MarshalJSON() ([]byte, error) {
+	steps := make([]typedJSON, len(p))
+	for i, pre := range p {
+		t, err := preprocessorType(pre)
+		if err != nil {
+			return nil, err
+		}
+		params, err := json.Marshal(pre)
+		if err != nil {
+			return nil, err
+		}
+		steps[i] = typedJSON{t, params}
+	}
+	return json.Marshal(steps)
+}
+
+// UnmarshalJSON restores preprocessors marshaled by MarshalJSON
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	var steps []typedJSON
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return err
+	}
+	*p = make(Pipeline, len(steps))
+	for i, s := range steps {
+		newPreprocessor, ok := preprocessors[s.Type]
+		if !ok {
+			return fmt.Errorf("unknown preprocessor %q", s.Type)
+		}
+		(*p)[i] = newPreprocessor()
+		if err := json.Unmarshal(s.Params, (*p)[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fitColumns computes two statistics of each selected column, leaving
+// the remaining columns at (0, 1)
+func fitColumns(rows [][]float64, columns []int, stats func(xx []float64) (float64, float64)) ([]float64, []float64) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	width := len(rows[0])
+	if len(columns) == 0 {
+		columns = make([]int, width)
+		for i := range columns {
+			columns[i] = i
+		}
+	}
+	a, b := make([]float64, width), make([]float64, width)
+	for i := range b {
+		b[i] = 1
+	}
+	column := make([]float64, len(rows))
+	for _, c := range columns {
+		for i, row := range rows {
+			column[i] = row[c]
+		}
+		a[c], b[c] = stats(column)
+	}
+	return a, b
+}
+
+func fitCategories(rows [][]float64, columns []int) [][]float64 {
+	res := make([][]float64, len(columns))
+	for i, c := range columns {
+		seen := make(map[float64]bool)
+		for _, row := range rows {
+			if !seen[row[c]] {
+				seen[row[c]] = true
+				res[i] = append(res[i], row[c])
+			}
+		}
+		sort.Float64s(res[i])
+	}
+	return res
+}
+
+// affine returns (x - shift) / scale, where a scale of 0 is taken as 1
+func affine(x, shift, scale []float64) []float64 {
+	res := make([]float64, len(x))
+	for i, v := range x {
+		if i >= len(shift) {
+			res[i] = v
+			continue
+		}
+		s := scale[i]
+		if s == 0 {
+			s = 1
+		}
+		res[i] = (v - shift[i]) / s
+	}
+	return res
+}
+
+// quantile of sorted xx, interpolating linearly between elements
+func quantile(xx []float64, q float64) float64 {
+	pos := q * float64(len(xx)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(xx) {
+		return xx[len(xx)-1]
+	}
+	return xx[i] + (pos-float64(i))*(xx[i+1]-xx[i])
+}
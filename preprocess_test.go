@@ -0,0 +1,79 @@
+package deep
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rows = [][]float64{
+	{1, 10, 2},
+	{2, 20, 1},
+	{3, 30, 2},
+	{10, 40, 3},
+}
+
+func Test_StandardScaler(t *testing.T) {
+	s := NewStandardScaler()
+	s.Fit(rows)
+	assert.Equal(t, []float64{4, 25, 2}, s.Mean)
+	assert.InDelta(t, math.Sqrt(500.0/3), s.Std[1], 1e-12)
+	assert.InDeltaSlice(t, []float64{-3 / s.Std[0], 0, 0}, s.Transform([]float64{1, 25, 2}), 1e-12)
+
+	// Only selected columns are scaled
+	s = NewStandardScaler(1)
+	s.Fit(rows)
+	assert.Equal(t, []float64{1, 0, 2}, s.Transform([]float64{1, 25, 2}))
+
+	// Constant columns are only shifted
+	s = NewStandardScaler()
+	s.Fit([][]float64{{5}, {5}})
+	assert.Equal(t, []float64{1}, s.Transform([]float64{6}))
+}
+
+func Test_MinMaxScaler(t *testing.T) {
+	s := NewMinMaxScaler()
+	s.Fit(rows)
+	assert.Equal(t, []float64{0, 0.5, 1}, s.Transform([]float64{1, 25, 3}))
+}
+
+func Test_RobustScaler(t *testing.T) {
+	s := NewRobustScaler(0)
+	s.Fit(rows)
+	// Quartiles of 1, 2, 3, 10 are 1.75 and 4.75
+	assert.Equal(t, 2.5, s.Median[0])
+	assert.Equal(t, 3.0, s.IQR[0])
+	assert.Equal(t, []float64{2.5, 10, 2}, s.Transform([]float64{10, 10, 2}))
+}
+
+func Test_Encoders(t *testing.T) {
+	e := NewOneHotEncoder(2)
+	e.Fit(rows)
+	assert.Equal(t, []float64{1, 2, 3}, e.Categories[0])
+	assert.Equal(t, []float64{1, 10, 0, 1, 0}, e.Transform([]float64{1, 10, 2}))
+	assert.Equal(t, []float64{1, 10, 0, 0, 0}, e.Transform([]float64{1, 10, 7}))
+
+	o := NewOrdinalEncoder(2)
+	o.Fit(rows)
+	assert.Equal(t, []float64{1, 10, 2}, o.Transform([]float64{1, 10, 3}))
+	assert.Equal(t, []float64{1, 10, -1}, o.Transform([]float64{1, 10, 7}))
+}
+
+func Test_Pipeline(t *testing.T) {
+	p := Pipeline{NewOneHotEncoder(2), NewMinMaxScaler()}
+	p.Fit(rows)
+	// The scaler is fitted on the encoded columns
+	assert.Len(t, p[1].(*MinMaxScaler).Min, 5)
+	assert.Equal(t, []float64{0, 0.5, 1, 0, 0}, p.Transform([]float64{1, 25, 1}))
+
+	bytes, err := json.Marshal(Pipeline{p[0], p[1], NewStandardScaler(), NewRobustScaler(), NewOrdinalEncoder()})
+	assert.NoError(t, err)
+	var restored Pipeline
+	assert.NoError(t, json.Unmarshal(bytes, &restored))
+	assert.Len(t, restored, 5)
+	assert.Equal(t, p.Transform([]float64{3, 30, 3}), restored[:2].Transform([]float64{3, 30, 3}))
+
+	assert.Error(t, json.Unmarshal([]byte(`[{"Type":"unknown"}]`), &restored))
+}
@@ -0,0 +1,203 @@
+// Package quantize implements post-training int8 quantization of networks.
+//
+// Weights are stored as int8 with per-layer or per-neuron scales, layer
+// inputs are quantized using ranges calibrated on a sample of examples,
+// and dot products are accumulated in int32.
+package quantize
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+)
+
+// Granularity determines how weight scales are shared
+type Granularity int
+
+const (
+	// PerLayer uses a single weight scale per layer
+	PerLayer Granularity = 0
+	// PerNeuron uses one weight scale per neuron
+	PerNeuron Granularity = 1
+)
+
+// Network is an int8 quantized network
+type Network struct {
+	Config      *deep.Config
+	Granularity Granularity
+	Layers      []*Layer
+}
+
+// Layer is a quantized fully connected layer
+type Layer struct {
+	Activation deep.ActivationType
+	// InputScale maps quantized inputs to real values
+	InputScale float64
+	// Weights is a [neurons][fanIn] matrix of quantized weights
+	Weights [][]int8
+	// Scales holds one scale per neuron, or a single scale for PerLayer
+	Scales []float64
+	// Bias is quantized with scale InputScale*Scales[j], nil if unbiased
+	Bias []int32 `json:",omitempty"`
+}
+
+// Quantize converts n to int8, calibrating activation ranges on calibration.
+// Networks with preprocessing or a calibrator attached are rejected, as
+// the quantized network would not reproduce their predictions.
+func Quantize(n *deep.Neural, calibration training.Examples, g Granularity) (*Network, error) {
+	if len(n.Preprocessing) > 0 || n.Calibrator != nil {
+		return nil, fmt.Errorf("quantize: cannot quantize preprocessing or calibration")
+	}
+	if len(calibration) == 0 {
+		return nil, fmt.Errorf("quantize: no calibration examples")
+	}
+	ranges, err := calibrate(n, calibration)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Network{
+		Config:      n.Config,
+		Granularity: g,
+		Layers:      make([]*Layer, len(n.Layers)),
+	}
+	for i, l := range n.Layers {
+		q.Layers[i] = quantizeLayer(l, ranges[i], g)
+	}
+	return q, nil
+}
+
+// calibrate returns the largest absolute input seen by each layer
+func calibrate(n *deep.Neural, examples training.Examples) ([]float64, error) {
+	ranges := make([]float64, len(n.Layers))
+	for _, e := range examples {
+		if err := n.Forward(e.Input); err != nil {
+			return nil, err
+		}
+		for _, x := range e.Input {
+			ranges[0] = math.Max(ranges[0], math.Abs(x))
+		}
+		for i := 1; i < len(n.Layers); i++ {
+			for _, neuron := range n.Layers[i-1].Neurons {
+				ranges[i] = math.Max(ranges[i], math.Abs(neuron.Value))
+			}
+		}
+	}
+	return ranges, nil
+}
+
+func quantizeLayer(l *deep.Layer, inputRange float64, g Granularity) *Layer {
+	q := &Layer{
+		Activation: l.A,
+		InputScale: scale(inputRange),
+		Weights:    make([][]int8, len(l.Neurons)),
+	}
+
+	maxAbs := make([]float64, len(l.Neurons))
+	for j, n := range l.Neurons {
+		for _, s := range n.In {
+			if !s.IsBias {
+				maxAbs[j] = math.Max(maxAbs[j], math.Abs(s.Weight))
+			}
+		}
+	}
+	if g == PerLayer {
+		q.Scales = []float64{scale(deep.Max(maxAbs))}
+	} else {
+		q.Scales = make([]float64, len(l.Neurons))
+		for j := range q.Scales {
+			q.Scales[j] = scale(maxAbs[j])
+		}
+	}
+
+	for j, n := range l.Neurons {
+		ws := q.weightScale(j)
+		for _, s := range n.In {
+			if s.IsBias {
+				if q.Bias == nil {
+					q.Bias = make([]int32, len(l.Neurons))
+				}
+				q.Bias[j] = clamp32(math.Round(s.Weight / (q.InputScale * ws)))
+				continue
+			}
+			q.Weights[j] = append(q.Weights[j], clamp8(math.Round(s.Weight/ws)))
+		}
+	}
+	return q
+}
+
+func (l *Layer) weightScale(neuron int) float64 {
+	if len(l.Scales) == 1 {
+		return l.Scales[0]
+	}
+	return l.Scales[neuron]
+}
+
+// Predict computes a forward pass using int8 arithmetic
+func (q *Network) Predict(input []float64) []float64 {
+	values := input
+	var quantized []int8
+	for _, l := range q.Layers {
+		if cap(quantized) < len(values) {
+			quantized = make([]int8, len(values))
+		}
+		quantized = quantized[:len(values)]
+		for i, x := range values {
+			quantized[i] = clamp8(math.Round(x / l.InputScale))
+		}
+		values = l.forward(quantized)
+	}
+	return values
+}
+
+func (l *Layer) forward(in []int8) []float64 {
+	out := make([]float64, len(l.Weights))
+	act := deep.GetActivation(l.Activation)
+	for j, w := range l.Weights {
+		var acc int32
+		for k, x := range in {
+			acc += int32(x) * int32(w[k])
+		}
+		if l.Bias != nil {
+			acc += l.Bias[j]
+		}
+		out[j] = act.F(float64(acc) * l.InputScale * l.weightScale(j))
+	}
+	if l.Activation == deep.ActivationSoftmax {
+		return deep.Softmax(out)
+	}
+	return out
+}
+
+// Marshal marshals the quantized network to JSON
+func (q *Network) Marshal() ([]byte, error) {
+	return json.Marshal(q)
+}
+
+// Unmarshal restores a quantized network from a JSON blob
+func Unmarshal(bytes []byte) (*Network, error) {
+	var q Network
+	if err := json.Unmarshal(bytes, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// scale maps [-maxAbs, maxAbs] onto [-127, 127]
+func scale(maxAbs float64) float64 {
+	if maxAbs == 0 {
+		return 1
+	}
+	return maxAbs / math.MaxInt8
+}
+
+func clamp8(x float64) int8 {
+	return int8(math.Max(-math.MaxInt8, math.Min(math.MaxInt8, x)))
+}
+
+func clamp32(x float64) int32 {
+	return int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, x)))
+}
@@ -0,0 +1,106 @@
+package quantize
+
+import (
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+func clusters(n int) training.Examples {
+	centers := [][]float64{{-2, -2}, {2, -2}, {0, 2}}
+	var examples training.Examples
+	for i := 0; i < n; i++ {
+		c := i % len(centers)
+		response := make([]float64, len(centers))
+		response[c] = 1
+		examples = append(examples, training.Example{
+			Input:    []float64{centers[c][0] + rand.NormFloat64()*0.5, centers[c][1] + rand.NormFloat64()*0.5},
+			Response: response,
+		})
+	}
+	return examples
+}
+
+func Test_Quantize(t *testing.T) {
+	rand.Seed(0)
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{8, 8, 3},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Weight:     deep.NewNormal(0.5, 0),
+		Bias:       true,
+	})
+	train, validation := clusters(300), clusters(150)
+	training.NewTrainer(training.NewSGD(0.01, 0.5, 0, false), 0).Train(n, train, nil, 50)
+
+	for _, g := range []Granularity{PerLayer, PerNeuron} {
+		q, err := Quantize(n, train[:50], g)
+		assert.Nil(t, err)
+		assert.Len(t, q.Layers, 3)
+		if g == PerLayer {
+			assert.Len(t, q.Layers[0].Scales, 1)
+		} else {
+			assert.Len(t, q.Layers[0].Scales, 8)
+		}
+
+		r := Evaluate(n, q, validation)
+		assert.True(t, r.Accuracy > 0.95, r.String())
+		assert.True(t, r.AccuracyDrop() < 0.02, r.String())
+		assert.True(t, r.Agreement > 0.97, r.String())
+		assert.True(t, r.MaxError < 0.1, r.String())
+		assert.InEpsilon(t, r.Loss, r.QuantizedLoss, 0.25)
+
+		for _, e := range validation[:10] {
+			assert.InEpsilon(t, 1, deep.Sum(q.Predict(e.Input)), 1e-9)
+		}
+	}
+
+	_, err := Quantize(n, nil, PerLayer)
+	assert.Error(t, err)
+
+	// Attached transforms are rejected, and ignored when evaluating
+	q, err := Quantize(n, train[:50], PerNeuron)
+	assert.Nil(t, err)
+	before := Evaluate(n, q, validation)
+	n.Calibrator = &deep.TemperatureScaling{Temperature: 5}
+	_, err = Quantize(n, train[:50], PerNeuron)
+	assert.Error(t, err)
+	assert.Equal(t, before, Evaluate(n, q, validation))
+	n.Calibrator = nil
+	n.Preprocessing = deep.Pipeline{deep.NewStandardScaler()}
+	_, err = Quantize(n, train[:50], PerNeuron)
+	assert.Error(t, err)
+}
+
+func Test_Marshal(t *testing.T) {
+	rand.Seed(0)
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 1},
+		Activation: deep.ActivationReLU,
+		Mode:       deep.ModeRegression,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	examples := clusters(20)
+	q, err := Quantize(n, examples, PerNeuron)
+	assert.Nil(t, err)
+	assert.NotNil(t, q.Layers[0].Bias)
+	assert.Nil(t, q.Layers[1].Bias)
+
+	dump, err := q.Marshal()
+	assert.Nil(t, err)
+	restored, err := Unmarshal(dump)
+	assert.Nil(t, err)
+
+	for _, e := range examples {
+		assert.Equal(t, q.Predict(e.Input), restored.Predict(e.Input))
+		assert.InDelta(t, n.Predict(e.Input)[0], restored.Predict(e.Input)[0], 0.1)
+	}
+}
@@ -0,0 +1,107 @@
+package quantize
+
+import (
+	"fmt"
+	"math"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+)
+
+// Report compares a quantized network against its float64 original
+type Report struct {
+	// Loss of the float64 and quantized networks
+	Loss, QuantizedLoss float64
+	// Accuracy of the float64 and quantized networks, for classification modes
+	Accuracy, QuantizedAccuracy float64
+	// Agreement is the fraction of examples where both networks predict the same class
+	Agreement float64
+	// MaxError is the largest absolute difference between outputs
+	MaxError float64
+}
+
+// AccuracyDrop is the loss in accuracy caused by quantization
+func (r Report) AccuracyDrop() float64 {
+	return r.Accuracy - r.QuantizedAccuracy
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("loss: %.4f -> %.4f, accuracy: %.4f -> %.4f (drop %.4f), agreement: %.4f, max error: %.4g",
+		r.Loss, r.QuantizedLoss, r.Accuracy, r.QuantizedAccuracy, r.AccuracyDrop(), r.Agreement, r.MaxError)
+}
+
+// Evaluate compares n and its quantized counterpart q on validation. Both
+// are evaluated on network inputs, bypassing any preprocessing or
+// calibrator attached to n, since q carries neither.
+func Evaluate(n *deep.Neural, q *Network, validation training.Examples) Report {
+	var r Report
+	if len(validation) == 0 {
+		return r
+	}
+	float, quantized := make([][]float64, len(validation)), make([][]float64, len(validation))
+	responses := make([][]float64, len(validation))
+	var correct, qCorrect, agree int
+	for i, e := range validation {
+		float[i], quantized[i] = output(n, e.Input), q.Predict(e.Input)
+		responses[i] = e.Response
+		for j := range float[i] {
+			r.MaxError = math.Max(r.MaxError, math.Abs(float[i][j]-quantized[i][j]))
+		}
+		if classify(n.Config.Mode, float[i], e.Response) {
+			correct++
+		}
+		if classify(n.Config.Mode, quantized[i], e.Response) {
+			qCorrect++
+		}
+		if classify(n.Config.Mode, quantized[i], classes(n.Config.Mode, float[i])) {
+			agree++
+		}
+	}
+
+	loss := deep.GetLoss(n.Config.Loss)
+	r.Loss, r.QuantizedLoss = loss.F(float, responses), loss.F(quantized, responses)
+	if n.Config.Mode != deep.ModeRegression {
+		total := float64(len(validation))
+		r.Accuracy, r.QuantizedAccuracy, r.Agreement = float64(correct)/total, float64(qCorrect)/total, float64(agree)/total
+	}
+	return r
+}
+
+// output returns the outputs of n, without preprocessing or calibration
+func output(n *deep.Neural, input []float64) []float64 {
+	n.Forward(input)
+	out := n.Layers[len(n.Layers)-1].Neurons
+	res := make([]float64, len(out))
+	for i, neuron := range out {
+		res[i] = neuron.Value
+	}
+	return res
+}
+
+// classify reports whether estimate predicts the classes in ideal
+func classify(mode deep.Mode, estimate, ideal []float64) bool {
+	if mode == deep.ModeMultiClass {
+		return deep.ArgMax(estimate) == deep.ArgMax(ideal)
+	}
+	for i := range estimate {
+		if (estimate[i] >= 0.5) != (ideal[i] >= 0.5) {
+			return false
+		}
+	}
+	return true
+}
+
+// classes returns the one-hot or thresholded classes predicted by estimate
+func classes(mode deep.Mode, estimate []float64) []float64 {
+	c := make([]float64, len(estimate))
+	if mode == deep.ModeMultiClass {
+		c[deep.ArgMax(estimate)] = 1
+		return c
+	}
+	for i, e := range estimate {
+		if e >= 0.5 {
+			c[i] = 1
+		}
+	}
+	return c
+}
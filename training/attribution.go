@@ -0,0 +1,163 @@
+package training
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Attribution assigns a value to each input of a network
+type Attribution struct {
+	Features []string  `json:"features"`
+	Values   []float64 `json:"values"`
+	// Std is the standard deviation of permutation importances over
+	// repeats
+	Std []float64 `json:"std,omitempty"`
+}
+
+// Ranked returns the feature indices ordered by decreasing absolute value
+func (a *Attribution) Ranked() []int {
+	res := make([]int, len(a.Values))
+	for i := range res {
+		res[i] = i
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return math.Abs(a.Values[res[i]]) > math.Abs(a.Values[res[j]])
+	})
+	return res
+}
+
+// String lists the features by decreasing absolute value
+func (a *Attribution) String() string {
+	var b strings.Builder
+	for _, i := range a.Ranked() {
+		fmt.Fprintf(&b, "%-16s %10.4f", a.Features[i], a.Values[i])
+		if a.Std != nil {
+			fmt.Fprintf(&b, " ± %.4f", a.Std[i])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Explainer attributes the predictions of a network to its inputs. Inputs
+// are network inputs aligned with Config.Inputs, i.e. features after any
+// preprocessing, and outputs are taken before any calibration.
+type Explainer struct {
+	network  *deep.Neural
+	features []string
+}
+
+// NewExplainer returns an explainer of n, naming its inputs by features
+// or by default x0, x1, ...
+func NewExplainer(n *deep.Neural, features ...string) (*Explainer, error) {
+	if features == nil {
+		features = make([]string, n.Config.Inputs)
+		for i := range features {
+			features[i] = fmt.Sprintf("x%d", i)
+		}
+	}
+	if len(features) != n.Config.Inputs {
+		return nil, fmt.Errorf("Invalid feature names - expected: %d got: %d", n.Config.Inputs, len(features))
+	}
+	return &Explainer{network: n, features: features}, nil
+}
+
+func (e *Explainer) attribution(values []float64) *Attribution {
+	return &Attribution{Features: e.features, Values: values}
+}
+
+// PermutationImportance is the mean increase of the loss over examples
+// when shuffling each feature, over repeats shuffles, 5 by default
+func (e *Explainer) PermutationImportance(examples Examples, repeats int) *Attribution {
+	n := e.network
+	repeats = iparam(repeats, 5)
+	baseline := crossValidate(n, examples)
+
+	permuted := make(Examples, len(examples))
+	for i, ex := range examples {
+		permuted[i] = Example{Input: append([]float64{}, ex.Input...), Response: ex.Response}
+	}
+
+	values, std := make([]float64, n.Config.Inputs), make([]float64, n.Config.Inputs)
+	increases := make([]float64, repeats)
+	for f := range values {
+		for r := range increases {
+			order := rand.Perm(len(examples))
+			for i := range permuted {
+				permuted[i].Input[f] = examples[order[i]].Input[f]
+			}
+			increases[r] = crossValidate(n, permuted) - baseline
+		}
+		for i := range permuted {
+			permuted[i].Input[f] = examples[i].Input[f]
+		}
+		values[f], std[f] = deep.Mean(increases), deep.StandardDeviation(increases)
+	}
+	a := e.attribution(values)
+	a.Std = std
+	return a
+}
+
+// Gradient returns the gradient of the given output with respect to the
+// input, the saliency of each feature
+func (e *Explainer) Gradient(input []float64, output int) (*Attribution, error) {
+	g, err := e.network.InputGradient(input, output)
+	if err != nil {
+		return nil, err
+	}
+	return e.attribution(g), nil
+}
+
+// GradientInput returns the gradient of the given output times the input
+func (e *Explainer) GradientInput(input []float64, output int) (*Attribution, error) {
+	g, err := e.network.InputGradient(input, output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range g {
+		g[i] *= input[i]
+	}
+	return e.attribution(g), nil
+}
+
+// IntegratedGradients integrates the gradient of the given output along
+// the straight path from baseline to input in steps steps, 50 by default.
+// The attributions sum to the difference in output between input and
+// baseline, which defaults to all zeros.
+func (e *Explainer) IntegratedGradients(input, baseline []float64, output, steps int) (*Attribution, error) {
+	steps = iparam(steps, 50)
+	if len(input) != e.network.Config.Inputs {
+		return nil, fmt.Errorf("Invalid input dimension - expected: %d got: %d", e.network.Config.Inputs, len(input))
+	}
+	if baseline == nil {
+		baseline = make([]float64, len(input))
+	}
+	if len(baseline) != len(input) {
+		return nil, fmt.Errorf("Invalid baseline dimension - expected: %d got: %d", len(input), len(baseline))
+	}
+	values := make([]float64, len(input))
+	point := make([]float64, len(input))
+	for s := 0; s < steps; s++ {
+		// Midpoint rule
+		alpha := (float64(s) + 0.5) / float64(steps)
+		for i := range point {
+			point[i] = baseline[i] + alpha*(input[i]-baseline[i])
+		}
+		g, err := e.network.InputGradient(point, output)
+		if err != nil {
+			return nil, err
+		}
+		for i := range g {
+			values[i] += g[i]
+		}
+	}
+	for i := range values {
+		values[i] *= (input[i] - baseline[i]) / float64(steps)
+	}
+	return e.attribution(values), nil
+}
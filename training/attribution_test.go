@@ -0,0 +1,108 @@
+package training
+
+import (
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func numericGradient(n *deep.Neural, input []float64, output int) []float64 {
+	res := make([]float64, len(input))
+	x := append([]float64{}, input...)
+	for i := range x {
+		h := 1e-6
+		x[i] = input[i] + h
+		up := n.Predict(x)[output]
+		x[i] = input[i] - h
+		down := n.Predict(x)[output]
+		x[i] = input[i]
+		res[i] = (up - down) / (2 * h)
+	}
+	return res
+}
+
+func Test_Gradient(t *testing.T) {
+	rand.Seed(0)
+	for _, mode := range []deep.Mode{deep.ModeMultiClass, deep.ModeBinary, deep.ModeRegression} {
+		for _, act := range []deep.ActivationType{deep.ActivationSigmoid, deep.ActivationTanh, deep.ActivationReLU} {
+			n := deep.NewNeural(&deep.Config{
+				Inputs:     3,
+				Layout:     []int{4, 3, 2},
+				Activation: act,
+				Mode:       mode,
+				Weight:     deep.NewNormal(1, 0),
+				Bias:       true,
+			})
+			e, err := NewExplainer(n)
+			assert.NoError(t, err)
+			input := []float64{0.3, -0.2, 0.7}
+			for output := 0; output < 2; output++ {
+				a, err := e.Gradient(input, output)
+				assert.NoError(t, err)
+				assert.InDeltaSlice(t, numericGradient(n, input, output), a.Values, 1e-6)
+			}
+		}
+	}
+}
+
+func Test_IntegratedGradients(t *testing.T) {
+	rand.Seed(0)
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     3,
+		Layout:     []int{5, 2},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeMultiClass,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	e, err := NewExplainer(n, "a", "b", "c")
+	assert.NoError(t, err)
+	input, baseline := []float64{1, -0.5, 2}, []float64{0, 0, 0}
+
+	// Completeness: attributions sum to the change in output
+	a, err := e.IntegratedGradients(input, nil, 1, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, a.Features)
+	assert.InDelta(t, n.Predict(input)[1]-n.Predict(baseline)[1], deep.Sum(a.Values), 1e-4)
+
+	g, _ := e.Gradient(input, 1)
+	gi, _ := e.GradientInput(input, 1)
+	for i := range gi.Values {
+		assert.InDelta(t, g.Values[i]*input[i], gi.Values[i], 1e-12)
+	}
+
+	_, err = e.Gradient(input, 2)
+	assert.Error(t, err)
+	_, err = e.IntegratedGradients([]float64{1}, nil, 0, 0)
+	assert.Error(t, err)
+	_, err = e.IntegratedGradients(input, []float64{0}, 0, 0)
+	assert.EqualError(t, err, "Invalid baseline dimension - expected: 3 got: 1")
+
+	_, err = NewExplainer(n, "a", "b")
+	assert.EqualError(t, err, "Invalid feature names - expected: 3 got: 2")
+}
+
+func Test_PermutationImportance(t *testing.T) {
+	rand.Seed(0)
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{1}, Mode: deep.ModeRegression})
+	// Only the first input matters
+	n.ApplyWeights([][][]float64{{{2, 0}}})
+	var examples Examples
+	for i := 0; i < 50; i++ {
+		x := []float64{rand.NormFloat64(), rand.NormFloat64()}
+		examples = append(examples, Example{x, []float64{2 * x[0]}})
+	}
+
+	e, err := NewExplainer(n)
+	assert.NoError(t, err)
+	a := e.PermutationImportance(examples, 3)
+	assert.True(t, a.Values[0] > 1)
+	assert.Equal(t, 0.0, a.Values[1])
+	assert.Equal(t, []int{0, 1}, a.Ranked())
+	assert.Len(t, a.Std, 2)
+	assert.Contains(t, a.String(), "x0")
+	// The examples are left unchanged
+	assert.Equal(t, 2*examples[3].Input[0], examples[3].Response[0])
+}
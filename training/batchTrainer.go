@@ -1,8 +1,9 @@
 package training
 
 import (
+	"context"
+	"io"
 	"sync"
-	"time"
 
 	deep "github.com/patrikeh/go-deep"
 )
@@ -13,61 +14,126 @@ type BatchTrainer struct {
 	verbosity   int
 	batchSize   int
 	parallelism int
-	solver      Solver
+	solver      VectorSolver
 	printer     *StatsPrinter
+	clipping    Clipping
+	regularizer regularizer
+	gradNorm    float64
+	callbacks   []Callback
+	history     *History
+	metrics     []Metric
 }
 
 type internalb struct {
-	deltas            [][][]float64
-	partialDeltas     [][][][]float64
-	accumulatedDeltas [][][]float64
-	moments           [][][]float64
+	deltas        [][][]float64
+	partialDeltas [][][]float64
+	params        *parameters
+	losses        []*exampleLoss
+	lossSums      []float64
 }
 
-func newBatchTraining(layers []*deep.Layer, parallelism int) *internalb {
+func newBatchTraining(n *deep.Neural, parallelism int) *internalb {
+	layers := n.Layers
+	params := newParameters(layers)
+	losses := make([]*exampleLoss, parallelism)
 	deltas := make([][][]float64, parallelism)
-	partialDeltas := make([][][][]float64, parallelism)
-	accumulatedDeltas := make([][][]float64, len(layers))
+	partialDeltas := make([][][]float64, parallelism)
 	for w := 0; w < parallelism; w++ {
+		losses[w] = newExampleLoss(n)
 		deltas[w] = make([][]float64, len(layers))
-		partialDeltas[w] = make([][][]float64, len(layers))
+		partialDeltas[w] = make([][]float64, len(layers))
 
 		for i, l := range layers {
 			deltas[w][i] = make([]float64, len(l.Neurons))
-			accumulatedDeltas[i] = make([][]float64, len(l.Neurons))
-			partialDeltas[w][i] = make([][]float64, len(l.Neurons))
-			for j, n := range l.Neurons {
-				partialDeltas[w][i][j] = make([]float64, len(n.In))
-				accumulatedDeltas[i][j] = make([]float64, len(n.In))
-			}
+			partialDeltas[w][i] = make([]float64, len(params.gradients[i]))
 		}
 	}
 	return &internalb{
-		deltas:            deltas,
-		partialDeltas:     partialDeltas,
-		accumulatedDeltas: accumulatedDeltas,
+		deltas:        deltas,
+		partialDeltas: partialDeltas,
+		params:        params,
+		losses:        losses,
+		lossSums:      make([]float64, parallelism),
 	}
 }
 
 // NewBatchTrainer returns a BatchTrainer
 func NewBatchTrainer(solver Solver, verbosity, batchSize, parallelism int) *BatchTrainer {
+	printer := NewStatsPrinter()
+	printer.verbosity = verbosity
 	return &BatchTrainer{
-		solver:      solver,
+		solver:      Vectorize(solver),
 		verbosity:   verbosity,
 		batchSize:   iparam(batchSize, 1),
 		parallelism: iparam(parallelism, 1),
-		printer:     NewStatsPrinter(),
+		printer:     printer,
+		history:     &History{},
 	}
 }
 
+// SetMetrics sets the metrics computed over the validation set every
+// epoch, replacing the defaults of the network mode
+func (t *BatchTrainer) SetMetrics(m ...Metric) {
+	t.metrics = m
+}
+
+// SetPrinter replaces the printer reporting training progress
+func (t *BatchTrainer) SetPrinter(p *StatsPrinter) {
+	p.verbosity = t.verbosity
+	t.printer = p
+}
+
+// AddCallback registers callbacks to be notified during training
+func (t *BatchTrainer) AddCallback(c ...Callback) {
+	t.callbacks = append(t.callbacks, c...)
+}
+
+// SetClipping sets the gradient clipping applied before every update
+func (t *BatchTrainer) SetClipping(c Clipping) {
+	t.clipping = c
+}
+
+// SetRegularization sets the weight regularization of every layer
+func (t *BatchTrainer) SetRegularization(r Regularization) {
+	t.regularizer.all = r
+}
+
+// SetLayerRegularization sets the weight regularization of layer i,
+// overriding SetRegularization
+func (t *BatchTrainer) SetLayerRegularization(i int, r Regularization) {
+	t.regularizer.set(i, r)
+}
+
+// History returns the per epoch history of the last training run
+func (t *BatchTrainer) History() *History {
+	return t.history
+}
+
+// GradientNorm returns the global L2 norm of the gradients of the last
+// update, before clipping
+func (t *BatchTrainer) GradientNorm() float64 {
+	return t.gradNorm
+}
+
 // Train trains n
 func (t *BatchTrainer) Train(n *deep.Neural, examples, validation Examples, iterations int) {
-	t.internalb = newBatchTraining(n.Layers, t.parallelism)
+	t.TrainContext(context.Background(), n, examples, validation, iterations)
+}
+
+// TrainContext trains n until done or ctx is done, in which case training
+// stops after the current batch and ctx.Err() is returned
+func (t *BatchTrainer) TrainContext(ctx context.Context, n *deep.Neural, examples, validation Examples, iterations int) error {
+	return t.TrainDataset(ctx, n, NewMemoryDataset(examples, true), validation, iterations)
+}
 
-	train := make(Examples, len(examples))
-	copy(train, examples)
+// TrainDataset trains n on iterations passes over examples, reading one
+// batch at a time. It stops on the first error of examples or when ctx is
+// done, returning the error.
+func (t *BatchTrainer) TrainDataset(ctx context.Context, n *deep.Neural, examples Dataset, validation Examples, iterations int) error {
+	t.internalb = newBatchTraining(n, t.parallelism)
 
 	workCh := make(chan Example, t.parallelism)
+	defer close(workCh)
 	nets := make([]*deep.Neural, t.parallelism)
 
 	wg := sync.WaitGroup{}
@@ -78,106 +144,107 @@ func (t *BatchTrainer) Train(n *deep.Neural, examples, validation Examples, iter
 			n := nets[id]
 			for e := range workCh {
 				n.Forward(e.Input)
+				t.lossSums[id] += t.losses[id].of(n, e.Response)
 				t.calculateDeltas(n, e.Response, id)
 				wg.Done()
 			}
 		}(i, workCh)
 	}
 
-	t.printer.Init(n)
+	t.history = &History{}
+	t.printer.rate = learningRate(t.solver)
+	t.printer.regularizer = &t.regularizer
+	t.printer.metrics = t.metrics
 	t.solver.Init(n.NumWeights())
 
-	ts := time.Now()
-	for it := 1; it <= iterations; it++ {
-		train.Shuffle()
-		batches := train.SplitSize(t.batchSize)
+	s := newSession(ctx, n, iterations, validation, t.metrics, t.solver, &t.regularizer,
+		append([]Callback{t.printer, t.history}, t.callbacks...))
+	s.trainBegin()
+
+	var err error
+	batch := make(Examples, 0, t.batchSize)
+	for it := 1; it <= iterations && !s.done(); it++ {
+		if err = examples.Reset(); err != nil {
+			break
+		}
+		s.epochBegin(it)
+
+		for bi := 0; err == nil && !s.done(); bi++ {
+			batch = batch[:0]
+			for len(batch) < t.batchSize {
+				var e Example
+				if e, err = examples.Next(); err != nil {
+					break
+				}
+				batch = append(batch, e)
+			}
+			if len(batch) == 0 {
+				break
+			}
 
-		for _, b := range batches {
 			currentWeights := n.Weights()
 			for _, n := range nets {
 				n.ApplyWeights(currentWeights)
 			}
 
-			wg.Add(len(b))
-			for _, item := range b {
+			wg.Add(len(batch))
+			for _, item := range batch {
 				workCh <- item
 			}
 			wg.Wait()
 
 			for _, wPD := range t.partialDeltas {
 				for i, iPD := range wPD {
-					iAD := t.accumulatedDeltas[i]
-					for j, jPD := range iPD {
-						jAD := iAD[j]
-						for k, v := range jPD {
-							jAD[k] += v
-							jPD[k] = 0
-						}
+					iAD := t.params.gradients[i]
+					for k, v := range iPD {
+						iAD[k] += v
+						iPD[k] = 0
 					}
 				}
 			}
 
-			t.update(n, it)
-		}
+			t.regularizer.gradients(n, t.params, len(batch))
+			t.gradNorm = t.params.clip(t.clipping)
+			t.params.update(n, t.solver, it)
+			t.regularizer.constrain(n)
 
-		if t.verbosity > 0 && it%t.verbosity == 0 && len(validation) > 0 {
-			t.printer.PrintProgress(n, validation, time.Since(ts), it)
+			var loss float64
+			for w, l := range t.lossSums {
+				loss += l
+				t.lossSums[w] = 0
+			}
+			s.batchEnd(bi, loss, len(batch), t.gradNorm)
+		}
+		if err == io.EOF {
+			err = nil
 		}
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+		s.epochEnd()
+	}
+	s.trainEnd()
+	if err != nil {
+		return err
 	}
+	return ctx.Err()
 }
 
 func (t *BatchTrainer) calculateDeltas(n *deep.Neural, ideal []float64, wid int) {
-	loss := deep.GetLoss(n.Config.Loss)
 	deltas := t.deltas[wid]
 	partialDeltas := t.partialDeltas[wid]
-	lastDeltas := deltas[len(n.Layers)-1]
 
-	for i, n := range n.Layers[len(n.Layers)-1].Neurons {
-		lastDeltas[i] = loss.Df(
-			n.Value,
-			ideal[i],
-			n.DActivate(n.Value))
-	}
-
-	for i := len(n.Layers) - 2; i >= 0; i-- {
-		l := n.Layers[i]
-		iD := deltas[i]
-		nextD := deltas[i+1]
-		for j, n := range l.Neurons {
-			var sum float64
-			for k, s := range n.Out {
-				sum += s.Weight * nextD[k]
-			}
-			iD[j] = n.DActivate(n.Value) * sum
-		}
-	}
+	outputDeltas(n, ideal, deltas[len(n.Layers)-1])
+	n.Backpropagate(deltas)
 
 	for i, l := range n.Layers {
 		iD := deltas[i]
 		iPD := partialDeltas[i]
+		var idx int
 		for j, n := range l.Neurons {
 			jD := iD[j]
-			jPD := iPD[j]
-			for k, s := range n.In {
-				jPD[k] += jD * s.In
-			}
-		}
-	}
-}
-
-func (t *BatchTrainer) update(n *deep.Neural, it int) {
-	var idx int
-	for i, l := range n.Layers {
-		iAD := t.accumulatedDeltas[i]
-		for j, n := range l.Neurons {
-			jAD := iAD[j]
-			for k, s := range n.In {
-				update := t.solver.Update(s.Weight,
-					jAD[k],
-					it,
-					idx)
-				s.Weight += update
-				jAD[k] = 0
+			for _, s := range n.In {
+				iPD[idx] += jD * s.In
 				idx++
 			}
 		}
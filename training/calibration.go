@@ -0,0 +1,130 @@
+package training
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Calibrate fits c on the outputs of n over held-out examples, and
+// attaches it to n so that Predict returns calibrated probabilities. A
+// TemperatureScaling scales outputs independently unless n is
+// ModeMultiClass.
+func Calibrate(n *deep.Neural, c deep.Calibrator, examples Examples) {
+	outputs, targets := make([][]float64, len(examples)), make([][]float64, len(examples))
+	for i, e := range examples {
+		outputs[i], targets[i] = output(n, e.Input), e.Response
+	}
+	if t, ok := c.(*deep.TemperatureScaling); ok {
+		t.Independent = n.Config.Mode != deep.ModeMultiClass
+	}
+	c.Fit(outputs, targets)
+	n.Calibrator = c
+}
+
+// Bin is a range of predicted probabilities in a reliability diagram
+type Bin struct {
+	Lower, Upper float64
+	// Confidence is the mean predicted probability in the bin
+	Confidence float64
+	// Accuracy is the observed frequency of the predicted event
+	Accuracy float64
+	Count    int
+}
+
+// ReliabilityDiagram compares predicted probabilities to observed
+// frequencies over equal width bins
+type ReliabilityDiagram struct {
+	Bins []Bin
+	// ECE is the expected calibration error, the mean absolute difference
+	// of confidence and accuracy weighted by the size of each bin
+	ECE float64
+	// MCE is the maximum calibration error over non-empty bins
+	MCE float64
+}
+
+// Reliability computes the reliability diagram of the calibrated outputs
+// of n over examples with bins bins, 10 by default
+func Reliability(n *deep.Neural, examples Examples, bins int) *ReliabilityDiagram {
+	predictions, targets := make([][]float64, len(examples)), make([][]float64, len(examples))
+	for i, e := range examples {
+		predictions[i], targets[i] = probabilities(n, e.Input), e.Response
+	}
+	return reliability(n.Config.Mode, predictions, targets, bins)
+}
+
+// reliability bins the top class probability of multiclass predictions,
+// and the probability of every output otherwise
+func reliability(mode deep.Mode, predictions, targets [][]float64, bins int) *ReliabilityDiagram {
+	bins = iparam(bins, 10)
+	r := &ReliabilityDiagram{Bins: make([]Bin, bins)}
+	for i := range r.Bins {
+		r.Bins[i].Lower, r.Bins[i].Upper = float64(i)/float64(bins), float64(i+1)/float64(bins)
+	}
+
+	var total int
+	add := func(confidence float64, hit bool) {
+		i := int(confidence * float64(bins))
+		if i >= bins {
+			i = bins - 1
+		} else if i < 0 {
+			i = 0
+		}
+		r.Bins[i].Confidence += confidence
+		if hit {
+			r.Bins[i].Accuracy++
+		}
+		r.Bins[i].Count++
+		total++
+	}
+	for i, p := range predictions {
+		if mode == deep.ModeMultiClass {
+			top := deep.ArgMax(p)
+			add(p[top], top == deep.ArgMax(targets[i]))
+			continue
+		}
+		for j := range p {
+			add(p[j], targets[i][j] >= 0.5)
+		}
+	}
+
+	for i := range r.Bins {
+		b := &r.Bins[i]
+		if b.Count == 0 {
+			continue
+		}
+		b.Confidence /= float64(b.Count)
+		b.Accuracy /= float64(b.Count)
+		gap := math.Abs(b.Confidence - b.Accuracy)
+		r.ECE += gap * float64(b.Count) / float64(total)
+		r.MCE = math.Max(r.MCE, gap)
+	}
+	return r
+}
+
+// String renders the diagram as a table with bars of the accuracy
+func (r *ReliabilityDiagram) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s %-10s %-10s %-8s\n", "Bin", "Confidence", "Accuracy", "Count")
+	for _, bin := range r.Bins {
+		fmt.Fprintf(&b, "%.2f-%.2f    %-10.4f %-10.4f %-8d %s\n",
+			bin.Lower, bin.Upper, bin.Confidence, bin.Accuracy, bin.Count, strings.Repeat("#", int(bin.Accuracy*20+0.5)))
+	}
+	fmt.Fprintf(&b, "ECE %.4f MCE %.4f\n", r.ECE, r.MCE)
+	return b.String()
+}
+
+// ECE is the expected calibration error over Bins bins, 10 by default
+type ECE struct {
+	Bins int
+}
+
+// Name of the metric
+func (m ECE) Name() string { return "ece" }
+
+// Compute computes the expected calibration error
+func (m ECE) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return reliability(mode, predictions, targets, m.Bins).ECE
+}
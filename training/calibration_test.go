@@ -0,0 +1,75 @@
+package training
+
+import (
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reliability(t *testing.T) {
+	predictions := [][]float64{{0.95}, {0.9}, {0.15}, {0.1}}
+	targets := [][]float64{{1}, {0}, {0}, {0}}
+	r := reliability(deep.ModeBinary, predictions, targets, 0)
+	assert.Len(t, r.Bins, 10)
+	assert.Equal(t, 2, r.Bins[9].Count)
+	assert.InDelta(t, 0.925, r.Bins[9].Confidence, 1e-12)
+	assert.InDelta(t, 0.5, r.Bins[9].Accuracy, 1e-12)
+	assert.Equal(t, 2, r.Bins[1].Count)
+	// Gaps of 0.425 and 0.125 in bins of 2
+	assert.InDelta(t, (2*0.425+2*0.125)/4, r.ECE, 1e-12)
+	assert.InDelta(t, 0.425, r.MCE, 1e-12)
+	assert.InDelta(t, r.ECE, ECE{}.Compute(deep.ModeBinary, predictions, targets), 1e-12)
+	assert.Contains(t, r.String(), "ECE")
+
+	// Multiclass predictions are binned by their top class
+	r = reliability(deep.ModeMultiClass, [][]float64{{0.7, 0.3}, {0.45, 0.55}}, [][]float64{{1, 0}, {1, 0}}, 5)
+	assert.Equal(t, 1, r.Bins[3].Count)
+	assert.Equal(t, 1.0, r.Bins[3].Accuracy)
+	assert.Equal(t, 0.0, r.Bins[2].Accuracy)
+}
+
+func Test_Calibrate(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{1}, Mode: deep.ModeBinary})
+	n.ApplyWeights([][][]float64{{{10}}})
+	// Overconfident: sigmoid(10x) for examples positive with probability sigmoid(x)
+	var examples Examples
+	for i := -20; i <= 20; i++ {
+		x := float64(i) / 5
+		positives := int(deep.Logistic(x, 1)*20 + 0.5)
+		for j := 0; j < 20; j++ {
+			examples = append(examples, Example{[]float64{x}, []float64{float64(btoi(j < positives))}})
+		}
+	}
+	before := Reliability(n, examples, 10).ECE
+
+	Calibrate(n, &deep.PlattScaling{}, examples)
+	assert.IsType(t, &deep.PlattScaling{}, n.Calibrator)
+	assert.InDelta(t, 0.1, n.Calibrator.(*deep.PlattScaling).A, 0.02)
+	assert.True(t, Reliability(n, examples, 10).ECE < before/2)
+
+	// Refitting starts from uncalibrated predictions
+	Calibrate(n, &deep.PlattScaling{}, examples)
+	assert.InDelta(t, 0.1, n.Calibrator.(*deep.PlattScaling).A, 0.02)
+
+	// Temperature scaling of sigmoid outputs scales each alone
+	multilabel := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{2}, Mode: deep.ModeMultiLabel})
+	multilabel.ApplyWeights([][][]float64{{{10}, {10}}})
+	for i := range examples {
+		examples[i].Response = []float64{examples[i].Response[0], examples[i].Response[0]}
+	}
+	Calibrate(multilabel, &deep.TemperatureScaling{}, examples)
+	c := multilabel.Calibrator.(*deep.TemperatureScaling)
+	assert.True(t, c.Independent)
+	assert.InDelta(t, 10, c.Temperature, 2)
+	p := multilabel.Predict([]float64{0.4})
+	assert.InDelta(t, deep.Logistic(0.4, 1), p[0], 0.05)
+	assert.Equal(t, p[0], p[1])
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+package training
+
+import (
+	"context"
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Progress describes the state of training as seen by callbacks
+type Progress struct {
+	Network *deep.Neural
+	Epoch   int
+	Epochs  int
+	// Batch is the index of the last batch within the epoch
+	Batch int
+	// Loss is the training loss of the last batch in BatchEnd, and the
+	// mean training loss over the epoch in EpochEnd
+	Loss float64
+	// ValidationLoss and Metrics are computed over the validation set at
+	// the end of every epoch. Metrics is nil without validation examples.
+	ValidationLoss float64
+	Metrics        map[string]float64
+	// GradientNorm is the global L2 norm of the last update before clipping
+	GradientNorm float64
+	LearningRate float64
+	Elapsed      time.Duration
+
+	stop bool
+}
+
+// Stop requests training to stop at the end of the current batch
+func (p *Progress) Stop() {
+	p.stop = true
+}
+
+// Stopped reports whether training has been requested to stop
+func (p *Progress) Stopped() bool {
+	return p.stop
+}
+
+// Callback is notified as training progresses
+type Callback interface {
+	TrainBegin(p *Progress)
+	TrainEnd(p *Progress)
+	EpochBegin(p *Progress)
+	EpochEnd(p *Progress)
+	BatchEnd(p *Progress)
+}
+
+// NopCallback implements Callback with no-ops, embed it to only implement
+// some of the hooks
+type NopCallback struct{}
+
+// TrainBegin is called before the first epoch
+func (NopCallback) TrainBegin(p *Progress) {}
+
+// TrainEnd is called after the last epoch
+func (NopCallback) TrainEnd(p *Progress) {}
+
+// EpochBegin is called at the start of every epoch
+func (NopCallback) EpochBegin(p *Progress) {}
+
+// EpochEnd is called at the end of every epoch
+func (NopCallback) EpochEnd(p *Progress) {}
+
+// BatchEnd is called after every update
+func (NopCallback) BatchEnd(p *Progress) {}
+
+// session tracks the progress of a single training run
+type session struct {
+	Progress
+	ctx         context.Context
+	callbacks   []Callback
+	solver      VectorSolver
+	validation  Examples
+	metrics     []Metric
+	regularizer *regularizer
+	start       time.Time
+
+	loss     float64
+	examples int
+}
+
+func newSession(ctx context.Context, n *deep.Neural, epochs int, validation Examples, metrics []Metric, solver VectorSolver, r *regularizer, callbacks []Callback) *session {
+	if metrics == nil {
+		metrics = DefaultMetrics(n.Config.Mode)
+	}
+	return &session{
+		Progress:    Progress{Network: n, Epochs: epochs},
+		ctx:         ctx,
+		callbacks:   callbacks,
+		solver:      solver,
+		validation:  validation,
+		metrics:     metrics,
+		regularizer: r,
+	}
+}
+
+// done reports whether training should stop, either on request of a
+// callback or because the context is done
+func (s *session) done() bool {
+	return s.Stopped() || s.ctx.Err() != nil
+}
+
+func (s *session) trainBegin() {
+	s.start = time.Now()
+	for _, c := range s.callbacks {
+		c.TrainBegin(&s.Progress)
+	}
+}
+
+func (s *session) trainEnd() {
+	s.Elapsed = time.Since(s.start)
+	for _, c := range s.callbacks {
+		c.TrainEnd(&s.Progress)
+	}
+}
+
+func (s *session) epochBegin(epoch int) {
+	s.Epoch, s.Batch = epoch, 0
+	s.loss, s.examples = 0, 0
+	s.Elapsed = time.Since(s.start)
+	for _, c := range s.callbacks {
+		c.EpochBegin(&s.Progress)
+	}
+}
+
+// batchEnd records an update computed from examples with a summed loss
+func (s *session) batchEnd(batch int, loss float64, examples int, gradNorm float64) {
+	s.loss += loss
+	s.examples += examples
+
+	s.Batch = batch
+	s.Loss = loss/float64(examples) + s.regularizer.penalty(s.Network)
+	s.GradientNorm = gradNorm
+	s.LearningRate = currentLearningRate(s.solver)
+	s.Elapsed = time.Since(s.start)
+	for _, c := range s.callbacks {
+		c.BatchEnd(&s.Progress)
+	}
+}
+
+func (s *session) epochEnd() {
+	if s.examples > 0 {
+		s.Loss = s.loss/float64(s.examples) + s.regularizer.penalty(s.Network)
+	}
+	if len(s.validation) > 0 {
+		s.ValidationLoss, s.Metrics = evaluate(s.Network, s.validation, s.regularizer, s.metrics)
+		if o, ok := s.solver.(LossObserver); ok {
+			o.Observe(s.Epoch, s.ValidationLoss)
+		}
+	}
+	s.Elapsed = time.Since(s.start)
+	for _, c := range s.callbacks {
+		c.EpochEnd(&s.Progress)
+	}
+}
+
+// currentLearningRate returns the learning rate of s, or 0 if s does not
+// expose one
+func currentLearningRate(s VectorSolver) float64 {
+	if a, ok := s.(interface{ LearningRate() float64 }); ok {
+		return a.LearningRate()
+	}
+	return 0
+}
+
+// exampleLoss computes the loss of single examples without allocating
+type exampleLoss struct {
+	loss            deep.Loss
+	estimate, ideal [][]float64
+}
+
+func newExampleLoss(n *deep.Neural) *exampleLoss {
+	return &exampleLoss{
+		loss:     deep.GetLoss(n.Config.Loss),
+		estimate: [][]float64{make([]float64, len(n.Layers[len(n.Layers)-1].Neurons))},
+		ideal:    make([][]float64, 1),
+	}
+}
+
+// of returns the loss of the last forward pass of n
+func (l *exampleLoss) of(n *deep.Neural, ideal []float64) float64 {
+	for i, neuron := range n.Layers[len(n.Layers)-1].Neurons {
+		l.estimate[0][i] = neuron.Value
+	}
+	l.ideal[0] = ideal
+	return l.loss.F(l.estimate, l.ideal)
+}
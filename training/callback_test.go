@@ -0,0 +1,227 @@
+package training
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	events []string
+	epochs []Progress
+	stopAt int
+}
+
+func (r *recorder) TrainBegin(p *Progress) { r.events = append(r.events, "train begin") }
+func (r *recorder) TrainEnd(p *Progress)   { r.events = append(r.events, "train end") }
+func (r *recorder) EpochBegin(p *Progress) { r.events = append(r.events, "epoch begin") }
+func (r *recorder) BatchEnd(p *Progress)   { r.events = append(r.events, "batch end") }
+
+func (r *recorder) EpochEnd(p *Progress) {
+	r.events = append(r.events, "epoch end")
+	r.epochs = append(r.epochs, *p)
+	if p.Epoch == r.stopAt {
+		p.Stop()
+	}
+}
+
+func callbackData() Examples {
+	return Examples{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{1, 1}, []float64{0}},
+	}
+}
+
+func callbackNet() *deep.Neural {
+	return deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{4, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+}
+
+func Test_Callbacks(t *testing.T) {
+	data := callbackData()
+
+	r := &recorder{}
+	trainer := NewBatchTrainer(NewAdam(0.1, 0, 0, 0), 0, 2, 2)
+	trainer.AddCallback(r)
+	trainer.Train(callbackNet(), data, data, 2)
+
+	epoch := []string{"epoch begin", "batch end", "batch end", "epoch end"}
+	expected := append([]string{"train begin"}, epoch...)
+	expected = append(expected, epoch...)
+	expected = append(expected, "train end")
+	assert.Equal(t, expected, r.events)
+
+	for i, p := range r.epochs {
+		assert.Equal(t, i+1, p.Epoch)
+		assert.Equal(t, 2, p.Epochs)
+		assert.Equal(t, 1, p.Batch)
+		assert.True(t, p.Loss > 0)
+		assert.True(t, p.ValidationLoss > 0)
+		assert.NotNil(t, p.Metrics)
+		assert.True(t, p.GradientNorm > 0)
+		assert.Equal(t, 0.1, p.LearningRate)
+	}
+
+	r = &recorder{}
+	online := NewTrainer(NewSGD(0.1, 0, 0, false), 0)
+	online.AddCallback(r)
+	online.Train(callbackNet(), data, nil, 1)
+	assert.Equal(t, []string{"train begin", "epoch begin", "batch end", "batch end", "batch end", "batch end", "epoch end", "train end"}, r.events)
+	assert.Nil(t, r.epochs[0].Metrics)
+}
+
+func Test_CallbackLoss(t *testing.T) {
+	data := callbackData()
+	n := callbackNet()
+
+	var losses []float64
+	trainer := NewTrainer(NewSGD(0.1, 0, 0, false), 0)
+	trainer.AddCallback(&lossRecorder{losses: &losses})
+	trainer.Train(n, data, nil, 1)
+
+	// The epoch loss is the mean of the batch losses
+	var sum float64
+	for _, l := range losses[:4] {
+		sum += l
+	}
+	assert.InDelta(t, sum/4, losses[4], 1e-12)
+}
+
+type lossRecorder struct {
+	NopCallback
+	losses *[]float64
+}
+
+func (r *lossRecorder) BatchEnd(p *Progress) { *r.losses = append(*r.losses, p.Loss) }
+func (r *lossRecorder) EpochEnd(p *Progress) { *r.losses = append(*r.losses, p.Loss) }
+
+func Test_CallbackStop(t *testing.T) {
+	data := callbackData()
+	for _, trainer := range []interface {
+		Trainer
+		AddCallback(...Callback)
+	}{
+		NewTrainer(NewSGD(0.1, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 2, 1),
+	} {
+		r := &recorder{stopAt: 3}
+		trainer.AddCallback(r)
+		trainer.Train(callbackNet(), data, nil, 10)
+		assert.Len(t, r.epochs, 3)
+		assert.Equal(t, "train end", r.events[len(r.events)-1])
+	}
+}
+
+type stopOnBatch struct {
+	NopCallback
+	batches int
+}
+
+func (s *stopOnBatch) BatchEnd(p *Progress) {
+	s.batches++
+	p.Stop()
+}
+
+func Test_CallbackStopBatch(t *testing.T) {
+	s := &stopOnBatch{}
+	trainer := NewTrainer(NewSGD(0.1, 0, 0, false), 0)
+	trainer.AddCallback(s)
+	trainer.Train(callbackNet(), callbackData(), nil, 10)
+	assert.Equal(t, 1, s.batches)
+}
+
+func Test_StatsPrinterCallback(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewStatsPrinter()
+	p.w = tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
+	p.verbosity = 2
+
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 1, Layout: []int{2}, Mode: deep.ModeMultiClass, Loss: deep.LossCrossEntropy,
+		Activation: deep.ActivationSigmoid, Weight: deep.NewNormal(1, 0),
+	})
+	progress := &Progress{Network: n, Epoch: 1, ValidationLoss: 0.5, Metrics: map[string]float64{"accuracy": 0.75}}
+	p.TrainBegin(progress)
+	p.EpochEnd(progress)
+	progress.Epoch = 2
+	p.EpochEnd(progress)
+	progress.Metrics = nil
+	progress.Epoch = 4
+	p.EpochEnd(progress)
+
+	assert.Equal(t, "Epochs Elapsed Loss (CE) Accuracy \n---    ---     ---       ---      \n2      0s      0.5000    0.7500   \n", buf.String())
+}
+
+type cancelOnBatch struct {
+	NopCallback
+	cancel  context.CancelFunc
+	after   int
+	batches int
+}
+
+func (c *cancelOnBatch) BatchEnd(p *Progress) {
+	c.batches++
+	if c.batches == c.after {
+		c.cancel()
+	}
+}
+
+func Test_TrainContext(t *testing.T) {
+	data := callbackData()
+	for _, trainer := range []interface {
+		TrainContext(context.Context, *deep.Neural, Examples, Examples, int) error
+		AddCallback(...Callback)
+		History() *History
+	}{
+		NewTrainer(NewSGD(0.1, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 1, 4),
+	} {
+		goroutines := runtime.NumGoroutine()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c := &cancelOnBatch{cancel: cancel, after: 6}
+		trainer.AddCallback(c)
+
+		n := callbackNet()
+		err := trainer.TrainContext(ctx, n, data, data, 100)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 6, c.batches)
+		// The interrupted epoch is not recorded
+		assert.Len(t, trainer.History().Records, 1)
+		for _, l := range n.Weights() {
+			for _, w := range l {
+				for _, v := range w {
+					assert.False(t, math.IsNaN(v))
+				}
+			}
+		}
+
+		// Workers are shut down
+		for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.True(t, runtime.NumGoroutine() <= goroutines)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 1, 2).TrainContext(ctx, callbackNet(), data, nil, 1e9)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	assert.NoError(t, NewTrainer(NewSGD(0.1, 0, 0, false), 0).TrainContext(context.Background(), callbackNet(), data, nil, 1))
+}
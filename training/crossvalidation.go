@@ -0,0 +1,304 @@
+package training
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Fold is a split of examples into a training and a validation set
+type Fold struct {
+	Train      Examples
+	Validation Examples
+}
+
+// KFold randomly partitions the examples into k folds of near equal size,
+// each validating once on one part and training on the rest. It returns
+// nil if k is not positive.
+func (e Examples) KFold(k int) []Fold {
+	if k <= 0 {
+		return nil
+	}
+	order := rand.Perm(len(e))
+	parts := make([][]int, k)
+	for i, j := range order {
+		parts[i%k] = append(parts[i%k], j)
+	}
+	return e.folds(parts)
+}
+
+// StratifiedKFold partitions the examples into k folds preserving the
+// proportion of each class, given by the argmax of the response, or by
+// the rounded response of single output examples. It returns nil if k is
+// not positive.
+func (e Examples) StratifiedKFold(k int) []Fold {
+	if k <= 0 {
+		return nil
+	}
+	classes := make(map[int][]int)
+	for i, ex := range e {
+		c := class(ex.Response)
+		classes[c] = append(classes[c], i)
+	}
+	labels := make([]int, 0, len(classes))
+	for c := range classes {
+		labels = append(labels, c)
+	}
+	sort.Ints(labels)
+
+	parts := make([][]int, k)
+	var next int
+	for _, c := range labels {
+		members := classes[c]
+		rand.Shuffle(len(members), func(i, j int) {
+			members[i], members[j] = members[j], members[i]
+		})
+		// Dealing on from the previous class keeps fold sizes balanced
+		for _, j := range members {
+			parts[next%k] = append(parts[next%k], j)
+			next++
+		}
+	}
+	return e.folds(parts)
+}
+
+// GroupKFold partitions the examples into k folds such that all examples
+// of a group, e.g. the samples of one patient, fall in the same fold.
+// groups holds the group of each example, and groups are assigned largest
+// first to the smallest fold. It fails if groups and examples differ in
+// length, or if there are fewer than k groups.
+func (e Examples) GroupKFold(k int, groups []string) ([]Fold, error) {
+	if len(groups) != len(e) {
+		return nil, fmt.Errorf("expected a group for each of %d examples, got %d", len(e), len(groups))
+	}
+	members := make(map[string][]int)
+	for i, g := range groups {
+		members[g] = append(members[g], i)
+	}
+	names := make([]string, 0, len(members))
+	for g := range members {
+		names = append(names, g)
+	}
+	if k <= 0 || len(names) < k {
+		return nil, fmt.Errorf("cannot split %d groups into %d folds", len(names), k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := members[names[i]], members[names[j]]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([][]int, k)
+	for _, g := range names {
+		smallest := 0
+		for i := range parts {
+			if len(parts[i]) < len(parts[smallest]) {
+				smallest = i
+			}
+		}
+		parts[smallest] = append(parts[smallest], members[g]...)
+	}
+	return e.folds(parts), nil
+}
+
+func (e Examples) folds(parts [][]int) []Fold {
+	folds := make([]Fold, len(parts))
+	for i := range parts {
+		for j, part := range parts {
+			for _, k := range part {
+				if i == j {
+					folds[i].Validation = append(folds[i].Validation, e[k])
+				} else {
+					folds[i].Train = append(folds[i].Train, e[k])
+				}
+			}
+		}
+	}
+	return folds
+}
+
+func class(response []float64) int {
+	if len(response) == 1 {
+		return int(deep.Round(response[0]))
+	}
+	return deep.ArgMax(response)
+}
+
+// CrossValidation trains and evaluates a new network on every fold
+type CrossValidation struct {
+	// Config returns the configuration of each new network
+	Config func() *deep.Config
+	// Trainer returns a new trainer for each fold
+	Trainer    func() Trainer
+	Iterations int
+	// Split partitions the examples into folds, KFold if nil
+	Split func(examples Examples, k int) []Fold
+	// Groups holds the group of each example, if set examples are split
+	// by GroupKFold instead of Split
+	Groups []string
+	// Metrics are computed on each validation fold, DefaultMetrics if nil
+	Metrics []Metric
+	// Parallelism is the number of folds trained concurrently. The output
+	// of trainers printing progress then interleaves, so they are best
+	// created with verbosity 0.
+	Parallelism int
+}
+
+// FoldResult is the evaluation of one fold
+type FoldResult struct {
+	Network *deep.Neural
+	Loss    float64
+	Metrics map[string]float64
+}
+
+// CrossValidationResult holds the evaluation of every fold, and the mean
+// and standard deviation over folds of the loss and of each metric
+type CrossValidationResult struct {
+	Folds []FoldResult
+	Mean  map[string]float64
+	Std   map[string]float64
+}
+
+// CrossValidate runs k-fold cross-validation, training each fold for
+// iterations epochs
+func CrossValidate(config func() *deep.Config, trainer func() Trainer, examples Examples, k, iterations int) (*CrossValidationResult, error) {
+	cv := &CrossValidation{
+		Config:     config,
+		Trainer:    trainer,
+		Iterations: iterations,
+	}
+	return cv.Run(context.Background(), examples, k)
+}
+
+// Run cross-validates over k folds of examples. Trainers supporting
+// TrainContext stop once ctx is cancelled.
+func (cv *CrossValidation) Run(ctx context.Context, examples Examples, k int) (*CrossValidationResult, error) {
+	if k < 2 || k > len(examples) {
+		return nil, fmt.Errorf("cannot split %d examples into %d folds", len(examples), k)
+	}
+	folds, err := cv.split(examples, k)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]FoldResult, len(folds))
+	errs := make([]error, len(folds))
+	sem := make(chan struct{}, iparam(cv.Parallelism, 1))
+	var wg sync.WaitGroup
+	for i := range folds {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = cv.fold(ctx, folds[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return summarize(results), nil
+}
+
+// split partitions examples into k folds, each training and validating on
+// at least one example
+func (cv *CrossValidation) split(examples Examples, k int) ([]Fold, error) {
+	var folds []Fold
+	switch {
+	case cv.Groups != nil:
+		var err error
+		if folds, err = examples.GroupKFold(k, cv.Groups); err != nil {
+			return nil, err
+		}
+	case cv.Split != nil:
+		folds = cv.Split(examples, k)
+	default:
+		folds = examples.KFold(k)
+	}
+	if len(folds) != k {
+		return nil, fmt.Errorf("expected %d folds, got %d", k, len(folds))
+	}
+	for i, f := range folds {
+		if len(f.Train) == 0 || len(f.Validation) == 0 {
+			return nil, fmt.Errorf("fold %d has %d training and %d validation examples", i, len(f.Train), len(f.Validation))
+		}
+	}
+	return folds, nil
+}
+
+func (cv *CrossValidation) fold(ctx context.Context, fold Fold) (FoldResult, error) {
+	if err := ctx.Err(); err != nil {
+		return FoldResult{}, err
+	}
+	n := deep.NewNeural(cv.Config())
+	trainer := cv.Trainer()
+	if t, ok := trainer.(interface {
+		TrainContext(context.Context, *deep.Neural, Examples, Examples, int) error
+	}); ok {
+		if err := t.TrainContext(ctx, n, fold.Train, fold.Validation, cv.Iterations); err != nil {
+			return FoldResult{}, err
+		}
+	} else {
+		trainer.Train(n, fold.Train, fold.Validation, cv.Iterations)
+	}
+
+	metrics := cv.Metrics
+	if metrics == nil {
+		metrics = DefaultMetrics(n.Config.Mode)
+	}
+	loss, values := evaluate(n, fold.Validation, nil, metrics)
+	return FoldResult{Network: n, Loss: loss, Metrics: values}, nil
+}
+
+func summarize(folds []FoldResult) *CrossValidationResult {
+	values := make(map[string][]float64)
+	for _, f := range folds {
+		values["loss"] = append(values["loss"], f.Loss)
+		for name, v := range f.Metrics {
+			values[name] = append(values[name], v)
+		}
+	}
+	res := &CrossValidationResult{
+		Folds: folds,
+		Mean:  make(map[string]float64, len(values)),
+		Std:   make(map[string]float64, len(values)),
+	}
+	for name, vv := range values {
+		res.Mean[name], res.Std[name] = deep.Mean(vv), deep.StandardDeviation(vv)
+	}
+	return res
+}
+
+// String lists the mean and standard deviation of the loss and metrics
+func (r *CrossValidationResult) String() string {
+	names := make([]string, 0, len(r.Mean))
+	for name := range r.Mean {
+		if name != "loss" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range append([]string{"loss"}, names...) {
+		std := r.Std[name]
+		if math.IsNaN(std) {
+			std = 0
+		}
+		fmt.Fprintf(&b, "%-12s %.4f ± %.4f\n", name, r.Mean[name], std)
+	}
+	return b.String()
+}
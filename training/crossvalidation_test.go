@@ -0,0 +1,143 @@
+package training
+
+import (
+	"context"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkFolds(t *testing.T, examples Examples, folds []Fold) {
+	seen := make(map[float64]int)
+	for _, f := range folds {
+		assert.Len(t, f.Train, len(examples)-len(f.Validation))
+		for _, e := range f.Validation {
+			seen[e.Input[0]]++
+		}
+	}
+	// Every example validates exactly once
+	assert.Len(t, seen, len(examples))
+	for _, count := range seen {
+		assert.Equal(t, 1, count)
+	}
+}
+
+func Test_KFold(t *testing.T) {
+	examples := numbered(10)
+	folds := examples.KFold(3)
+	assert.Len(t, folds, 3)
+	checkFolds(t, examples, folds)
+	for _, f := range folds {
+		assert.True(t, len(f.Validation) == 3 || len(f.Validation) == 4)
+	}
+	assert.Nil(t, examples.KFold(0))
+	assert.Nil(t, examples.KFold(-1))
+}
+
+func Test_StratifiedKFold(t *testing.T) {
+	var examples Examples
+	for i := 0; i < 12; i++ {
+		response := []float64{0, 0, 0}
+		// Classes of 6, 3 and 3 examples
+		response[[]int{0, 0, 1, 2}[i%4]] = 1
+		examples = append(examples, Example{[]float64{float64(i)}, response})
+	}
+	folds := examples.StratifiedKFold(3)
+	checkFolds(t, examples, folds)
+	for _, f := range folds {
+		counts := make([]int, 3)
+		for _, e := range f.Validation {
+			counts[deep.ArgMax(e.Response)]++
+		}
+		assert.Equal(t, []int{2, 1, 1}, counts)
+	}
+
+	// Single outputs are stratified by their rounded value
+	folds = numbered(10).StratifiedKFold(2)
+	for _, f := range folds {
+		assert.Len(t, f.Validation, 5)
+	}
+	assert.Nil(t, examples.StratifiedKFold(0))
+	assert.Nil(t, examples.StratifiedKFold(-1))
+}
+
+func Test_GroupKFold(t *testing.T) {
+	examples := numbered(6)
+	groups := []string{"a", "a", "a", "b", "c", "c"}
+	folds, err := examples.GroupKFold(2, groups)
+	assert.NoError(t, err)
+	checkFolds(t, examples, folds)
+	assert.Equal(t, []float64{0, 1, 2}, drain(t, NewMemoryDataset(folds[0].Validation, false)))
+	assert.Equal(t, []float64{4, 5, 3}, drain(t, NewMemoryDataset(folds[1].Validation, false)))
+
+	_, err = examples.GroupKFold(2, groups[:5])
+	assert.Error(t, err)
+	_, err = examples.GroupKFold(2, append(groups, "d"))
+	assert.Error(t, err)
+	_, err = examples.GroupKFold(4, groups)
+	assert.Error(t, err)
+	_, err = examples.GroupKFold(-1, groups)
+	assert.Error(t, err)
+}
+
+func Test_CrossValidate(t *testing.T) {
+	var examples Examples
+	for i := 0; i < 5; i++ {
+		examples = append(examples, callbackData()...)
+	}
+	config := func() *deep.Config { return callbackNet().Config }
+	trainer := func() Trainer { return NewTrainer(NewAdam(0.05, 0, 0, 0), 0) }
+
+	res, err := CrossValidate(config, trainer, examples, 4, 50)
+	assert.NoError(t, err)
+	assert.Len(t, res.Folds, 4)
+	var sum float64
+	for _, f := range res.Folds {
+		assert.NotNil(t, f.Network)
+		sum += f.Metrics["accuracy"]
+	}
+	assert.InDelta(t, sum/4, res.Mean["accuracy"], 1e-12)
+	assert.Contains(t, res.Std, "loss")
+	assert.Contains(t, res.String(), "accuracy")
+
+	cv := &CrossValidation{
+		Config:      config,
+		Trainer:     func() Trainer { return NewBatchTrainer(NewAdam(0.05, 0, 0, 0), 0, 4, 2) },
+		Iterations:  5,
+		Split:       Examples.StratifiedKFold,
+		Metrics:     []Metric{NewF1(Macro, 0)},
+		Parallelism: 4,
+	}
+	res, err = cv.Run(context.Background(), examples, 5)
+	assert.NoError(t, err)
+	assert.Len(t, res.Folds, 5)
+	assert.Contains(t, res.Mean, "f1_macro")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cv.Run(ctx, examples, 5)
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = CrossValidate(config, trainer, examples, 1, 1)
+	assert.Error(t, err)
+
+	// Fewer groups than folds, and a split leaving a fold empty
+	groups := make([]string, len(examples))
+	for i := range groups {
+		groups[i] = []string{"a", "b"}[i%2]
+	}
+	cv = &CrossValidation{Config: config, Trainer: trainer, Iterations: 1, Groups: groups}
+	_, err = cv.Run(context.Background(), examples, 3)
+	assert.Error(t, err)
+	cv.Groups = nil
+	cv.Split = func(e Examples, k int) []Fold {
+		return append(e.KFold(k-1), Fold{Train: e})
+	}
+	_, err = cv.Run(context.Background(), examples, 3)
+	assert.Error(t, err)
+	cv.Groups = groups
+	res, err = cv.Run(context.Background(), examples, 2)
+	assert.NoError(t, err)
+	assert.Len(t, res.Folds, 2)
+}
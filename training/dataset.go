@@ -0,0 +1,292 @@
+package training
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// Dataset is an iterator over examples, allowing training on data that
+// does not fit in memory
+type Dataset interface {
+	// Next returns the next example, or io.EOF once the dataset is exhausted
+	Next() (Example, error)
+	// Reset starts a new pass over the dataset
+	Reset() error
+}
+
+// Sized is implemented by datasets that may know their number of
+// examples, Len returns -1 if unknown
+type Sized interface {
+	Len() int
+}
+
+// MemoryDataset iterates over in-memory examples, optionally in a new
+// random order every pass. The examples themselves are neither copied
+// nor reordered.
+type MemoryDataset struct {
+	examples Examples
+	order    []int
+	shuffle  bool
+	pos      int
+}
+
+// NewMemoryDataset returns a dataset over examples
+func NewMemoryDataset(examples Examples, shuffle bool) *MemoryDataset {
+	order := make([]int, len(examples))
+	for i := range order {
+		order[i] = i
+	}
+	return &MemoryDataset{
+		examples: examples,
+		order:    order,
+		shuffle:  shuffle,
+	}
+}
+
+// Next returns the next example
+func (d *MemoryDataset) Next() (Example, error) {
+	if d.pos >= len(d.order) {
+		return Example{}, io.EOF
+	}
+	d.pos++
+	return d.examples[d.order[d.pos-1]], nil
+}
+
+// Reset rewinds the dataset, reshuffling if set to shuffle
+func (d *MemoryDataset) Reset() error {
+	d.pos = 0
+	if d.shuffle {
+		rand.Shuffle(len(d.order), func(i, j int) {
+			d.order[i], d.order[j] = d.order[j], d.order[i]
+		})
+	}
+	return nil
+}
+
+// Len returns the number of examples
+func (d *MemoryDataset) Len() int {
+	return len(d.examples)
+}
+
+// LineDataset reads one example per line of a file, skipping blank lines
+type LineDataset struct {
+	path    string
+	parse   func(line string) (Example, error)
+	file    *os.File
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewLineDataset opens a line-delimited file of examples, parsing each line
+// with parse
+func NewLineDataset(path string, parse func(line string) (Example, error)) (*LineDataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &LineDataset{path: path, parse: parse, file: f}
+	d.rewind()
+	return d, nil
+}
+
+func (d *LineDataset) rewind() {
+	d.scanner = bufio.NewScanner(d.file)
+	d.scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	d.line = 0
+}
+
+// Next parses the next line, errors are reported with the line number
+func (d *LineDataset) Next() (Example, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+		e, err := d.parse(line)
+		if err != nil {
+			return Example{}, fmt.Errorf("%s:%d: %v", d.path, d.line, err)
+		}
+		return e, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Example{}, fmt.Errorf("%s:%d: %v", d.path, d.line+1, err)
+	}
+	return Example{}, io.EOF
+}
+
+// Reset seeks back to the start of the file
+func (d *LineDataset) Reset() error {
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.rewind()
+	return nil
+}
+
+// Close closes the underlying file
+func (d *LineDataset) Close() error {
+	return d.file.Close()
+}
+
+// ShuffleBuffer approximately shuffles a dataset by sampling from a buffer
+// of upcoming examples
+type ShuffleBuffer struct {
+	dataset Dataset
+	size    int
+	buffer  []Example
+	done    bool
+}
+
+// NewShuffleBuffer returns a dataset shuffling d through a buffer of size
+// examples, where a larger buffer gives a more uniform shuffle
+func NewShuffleBuffer(d Dataset, size int) *ShuffleBuffer {
+	size = iparam(size, 1000)
+	return &ShuffleBuffer{
+		dataset: d,
+		size:    size,
+		buffer:  make([]Example, 0, size),
+	}
+}
+
+// Next returns a random example from the buffer, replacing it with the
+// next example of the underlying dataset
+func (d *ShuffleBuffer) Next() (Example, error) {
+	for !d.done && len(d.buffer) < d.size {
+		e, err := d.dataset.Next()
+		if err == io.EOF {
+			d.done = true
+			break
+		}
+		if err != nil {
+			return Example{}, err
+		}
+		d.buffer = append(d.buffer, e)
+	}
+	if len(d.buffer) == 0 {
+		return Example{}, io.EOF
+	}
+	i, last := rand.Intn(len(d.buffer)), len(d.buffer)-1
+	e := d.buffer[i]
+	d.buffer[i] = d.buffer[last]
+	d.buffer = d.buffer[:last]
+	return e, nil
+}
+
+// Reset empties the buffer and resets the underlying dataset
+func (d *ShuffleBuffer) Reset() error {
+	d.buffer, d.done = d.buffer[:0], false
+	return d.dataset.Reset()
+}
+
+// Len returns the length of the underlying dataset, if known
+func (d *ShuffleBuffer) Len() int {
+	if s, ok := d.dataset.(Sized); ok {
+		return s.Len()
+	}
+	return -1
+}
+
+// Prefetch reads batches of a dataset in a background goroutine, so that
+// the next batch loads while the current one trains
+type Prefetch struct {
+	dataset Dataset
+	size    int
+	batches chan prefetched
+	stop    chan struct{}
+	batch   []Example
+	err     error
+}
+
+type prefetched struct {
+	examples []Example
+	err      error
+}
+
+// NewPrefetch returns a dataset prefetching batches of size examples
+// from d. Close must be called to stop the background goroutine if the
+// dataset is not read until exhausted.
+func NewPrefetch(d Dataset, size int) *Prefetch {
+	return &Prefetch{
+		dataset: d,
+		size:    iparam(size, 256),
+	}
+}
+
+func (d *Prefetch) start() {
+	batches, stop := make(chan prefetched, 1), make(chan struct{})
+	d.batches, d.stop = batches, stop
+	go func() {
+		defer close(batches)
+		for {
+			batch := make([]Example, 0, d.size)
+			var err error
+			for len(batch) < d.size {
+				var e Example
+				if e, err = d.dataset.Next(); err != nil {
+					break
+				}
+				batch = append(batch, e)
+			}
+			select {
+			case batches <- prefetched{batch, err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Next returns the next prefetched example
+func (d *Prefetch) Next() (Example, error) {
+	if d.batches == nil {
+		d.start()
+	}
+	for len(d.batch) == 0 {
+		if d.err != nil {
+			return Example{}, d.err
+		}
+		p, ok := <-d.batches
+		if !ok {
+			return Example{}, io.EOF
+		}
+		d.batch, d.err = p.examples, p.err
+	}
+	e := d.batch[0]
+	d.batch = d.batch[1:]
+	return e, nil
+}
+
+// Reset stops prefetching and resets the underlying dataset
+func (d *Prefetch) Reset() error {
+	d.Close()
+	d.batch, d.err = nil, nil
+	return d.dataset.Reset()
+}
+
+// Close stops the background goroutine
+func (d *Prefetch) Close() error {
+	if d.batches == nil {
+		return nil
+	}
+	close(d.stop)
+	for range d.batches {
+	}
+	d.batches = nil
+	return nil
+}
+
+// Len returns the length of the underlying dataset, if known
+func (d *Prefetch) Len() int {
+	if s, ok := d.dataset.(Sized); ok {
+		return s.Len()
+	}
+	return -1
+}
@@ -0,0 +1,177 @@
+package training
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func numbered(n int) Examples {
+	res := make(Examples, n)
+	for i := range res {
+		res[i] = Example{[]float64{float64(i)}, []float64{float64(i % 2)}}
+	}
+	return res
+}
+
+func drain(t *testing.T, d Dataset) []float64 {
+	assert.NoError(t, d.Reset())
+	var res []float64
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			return res
+		}
+		assert.NoError(t, err)
+		res = append(res, e.Input[0])
+	}
+}
+
+func sorted(xx []float64) []float64 {
+	res := append([]float64{}, xx...)
+	sort.Float64s(res)
+	return res
+}
+
+func Test_MemoryDataset(t *testing.T) {
+	examples := numbered(10)
+	all := drain(t, NewMemoryDataset(examples, false))
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, all)
+
+	d := NewMemoryDataset(examples, true)
+	assert.Equal(t, 10, d.Len())
+	assert.Equal(t, all, sorted(drain(t, d)))
+	// The examples are left in place
+	assert.Equal(t, numbered(10), examples)
+}
+
+func parseLine(line string) (Example, error) {
+	fields := strings.Split(line, ",")
+	x, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Example{}, err
+	}
+	y, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return Example{}, err
+	}
+	return Example{[]float64{x}, []float64{y}}, nil
+}
+
+func writeFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "dataset")
+	assert.NoError(t, err)
+	path := filepath.Join(dir, "data.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func Test_LineDataset(t *testing.T) {
+	path := writeFile(t, "1,0\n\n2,1\n3,0\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	d, err := NewLineDataset(path, parseLine)
+	assert.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, []float64{1, 2, 3}, drain(t, d))
+	assert.Equal(t, []float64{1, 2, 3}, drain(t, d))
+
+	bad := writeFile(t, "1,0\n2,x\n")
+	defer os.RemoveAll(filepath.Dir(bad))
+	d, err = NewLineDataset(bad, parseLine)
+	assert.NoError(t, err)
+	defer d.Close()
+	d.Next()
+	_, err = d.Next()
+	assert.EqualError(t, err, bad+`:2: strconv.ParseFloat: parsing "x": invalid syntax`)
+
+	_, err = NewLineDataset(filepath.Join(filepath.Dir(bad), "missing"), parseLine)
+	assert.Error(t, err)
+}
+
+func Test_ShuffleBuffer(t *testing.T) {
+	d := NewShuffleBuffer(NewMemoryDataset(numbered(100), false), 10)
+	assert.Equal(t, 100, d.Len())
+
+	first := drain(t, d)
+	assert.Equal(t, drain(t, NewMemoryDataset(numbered(100), false)), sorted(first))
+	assert.NotEqual(t, sorted(first), first)
+	// An example cannot be yielded before it enters the buffer
+	for i, x := range first {
+		assert.True(t, x < float64(i+10))
+	}
+}
+
+func Test_Prefetch(t *testing.T) {
+	d := NewPrefetch(NewMemoryDataset(numbered(25), false), 4)
+	assert.Equal(t, 25, d.Len())
+	expected := drain(t, NewMemoryDataset(numbered(25), false))
+	assert.Equal(t, expected, drain(t, d))
+	assert.Equal(t, expected, drain(t, d))
+
+	// Reset in the middle of a pass
+	d.Next()
+	assert.Equal(t, expected, drain(t, d))
+
+	d.Next()
+	assert.NoError(t, d.Close())
+	assert.NoError(t, d.Close())
+}
+
+func newSingleInputNet() *deep.Neural {
+	return deep.NewNeural(&deep.Config{
+		Inputs:     1,
+		Layout:     []int{2, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+}
+
+type failingDataset struct {
+	*MemoryDataset
+	after int
+}
+
+func (d *failingDataset) Next() (Example, error) {
+	if d.pos == d.after {
+		return Example{}, errors.New("read failed")
+	}
+	return d.MemoryDataset.Next()
+}
+
+func Test_TrainDataset(t *testing.T) {
+	path := writeFile(t, "0,0\n1,1\n0,0\n1,1\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for _, trainer := range []interface {
+		TrainDataset(context.Context, *deep.Neural, Dataset, Examples, int) error
+		History() *History
+	}{
+		NewTrainer(NewSGD(0.1, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 3, 2),
+	} {
+		d, err := NewLineDataset(path, parseLine)
+		assert.NoError(t, err)
+		n := newSingleInputNet()
+		assert.NoError(t, trainer.TrainDataset(context.Background(), n, NewPrefetch(d, 2), nil, 3))
+		assert.Len(t, trainer.History().Records, 3)
+		d.Close()
+
+		failing := &failingDataset{NewMemoryDataset(numbered(10), false), 5}
+		err = trainer.TrainDataset(context.Background(), newSingleInputNet(), failing, nil, 3)
+		assert.EqualError(t, err, "read failed")
+		assert.Len(t, trainer.History().Records, 0)
+	}
+}
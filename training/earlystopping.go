@@ -0,0 +1,103 @@
+package training
+
+import "math"
+
+// EarlyStopping stops training once a monitored value has not improved
+// for a number of epochs, and restores the best weights seen when
+// training ends
+type EarlyStopping struct {
+	NopCallback
+	monitor  string
+	patience int
+	minDelta float64
+	maximize bool
+
+	best         float64
+	weights      [][][]float64
+	wait         int
+	bestEpoch    int
+	stoppedEpoch int
+}
+
+// NewEarlyStopping returns an EarlyStopping callback. monitor is either
+// "loss" for the validation loss, "train_loss" for the training loss or
+// the name of a validation metric, and defaults to "loss". An epoch counts
+// as an improvement if it betters the best value by more than minDelta;
+// maximize is set for metrics where higher is better.
+func NewEarlyStopping(monitor string, patience int, minDelta float64, maximize bool) *EarlyStopping {
+	if monitor == "" {
+		monitor = "loss"
+	}
+	return &EarlyStopping{
+		monitor:  monitor,
+		patience: iparam(patience, 10),
+		minDelta: minDelta,
+		maximize: maximize,
+	}
+}
+
+// TrainBegin resets the state of the callback
+func (e *EarlyStopping) TrainBegin(p *Progress) {
+	e.best = math.Inf(1)
+	if e.maximize {
+		e.best = math.Inf(-1)
+	}
+	e.weights = nil
+	e.wait, e.bestEpoch, e.stoppedEpoch = 0, 0, 0
+}
+
+// EpochEnd records the monitored value and stops training if it has not
+// improved for patience epochs
+func (e *EarlyStopping) EpochEnd(p *Progress) {
+	value, ok := monitored(p, e.monitor)
+	if !ok {
+		return
+	}
+	if e.improved(value) {
+		e.best, e.wait, e.bestEpoch = value, 0, p.Epoch
+		e.weights = p.Network.Weights()
+		return
+	}
+	e.wait++
+	if e.wait >= e.patience {
+		e.stoppedEpoch = p.Epoch
+		p.Stop()
+	}
+}
+
+// TrainEnd restores the best weights
+func (e *EarlyStopping) TrainEnd(p *Progress) {
+	if e.weights != nil {
+		p.Network.ApplyWeights(e.weights)
+	}
+}
+
+// BestEpoch returns the epoch with the best monitored value
+func (e *EarlyStopping) BestEpoch() int {
+	return e.bestEpoch
+}
+
+// StoppedEpoch returns the epoch training was stopped at, or 0 if it ran
+// to completion
+func (e *EarlyStopping) StoppedEpoch() int {
+	return e.stoppedEpoch
+}
+
+func (e *EarlyStopping) improved(value float64) bool {
+	if e.maximize {
+		return value > e.best+e.minDelta
+	}
+	return value < e.best-e.minDelta
+}
+
+// monitored looks up a value of p by name
+func monitored(p *Progress, name string) (float64, bool) {
+	switch name {
+	case "train_loss":
+		return p.Loss, true
+	case "loss":
+		return p.ValidationLoss, p.Metrics != nil
+	}
+	v, ok := p.Metrics[name]
+	return v, ok
+}
@@ -0,0 +1,70 @@
+package training
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EarlyStopping(t *testing.T) {
+	n := callbackNet()
+	e := NewEarlyStopping("", 2, 0.01, false)
+
+	p := &Progress{Network: n, Metrics: map[string]float64{}}
+	e.TrainBegin(p)
+
+	var best [][][]float64
+	losses := []float64{1, 0.5, 0.495, 0.6, 0.3}
+	for i, loss := range losses {
+		p.Epoch, p.ValidationLoss = i+1, loss
+		e.EpochEnd(p)
+		if i == 1 {
+			best = n.Weights()
+		}
+		// Perturb the weights every epoch
+		n.Layers[0].Neurons[0].In[0].Weight += 1
+		if p.Stopped() {
+			break
+		}
+	}
+
+	assert.True(t, p.Stopped())
+	assert.Equal(t, 4, e.StoppedEpoch())
+	assert.Equal(t, 2, e.BestEpoch())
+
+	e.TrainEnd(p)
+	assert.Equal(t, best, n.Weights())
+}
+
+func Test_EarlyStoppingMetric(t *testing.T) {
+	e := NewEarlyStopping("accuracy", 1, 0, true)
+	p := &Progress{Network: callbackNet()}
+	e.TrainBegin(p)
+
+	// Missing values are ignored
+	p.Epoch = 1
+	e.EpochEnd(p)
+	assert.Equal(t, 0, e.BestEpoch())
+
+	for i, acc := range []float64{0.5, 0.75, 0.75} {
+		p.Epoch, p.Metrics = i+2, map[string]float64{"accuracy": acc}
+		e.EpochEnd(p)
+	}
+	assert.Equal(t, 3, e.BestEpoch())
+	assert.Equal(t, 4, e.StoppedEpoch())
+}
+
+func Test_EarlyStoppingTraining(t *testing.T) {
+	data := callbackData()
+	n := callbackNet()
+
+	e := NewEarlyStopping("train_loss", 5, 1e-3, false)
+	trainer := NewTrainer(NewSGD(0.1, 0, 0, false), 0)
+	trainer.AddCallback(e)
+	trainer.Train(n, data, nil, 100000)
+
+	// The training loss stops improving by more than min delta
+	assert.True(t, e.StoppedEpoch() > 0)
+	assert.True(t, e.StoppedEpoch() < 100000)
+	assert.Equal(t, e.StoppedEpoch()-5, e.BestEpoch())
+}
@@ -0,0 +1,113 @@
+package training
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// GradientCheck compares the weight gradients computed by backpropagation
+// with central finite differences of the loss. Gradients are indexed by
+// layer, neuron and input synapse, the bias synapse last.
+type GradientCheck struct {
+	Analytic [][][]float64
+	Numeric  [][][]float64
+	// Error is the relative error |a-n| / max(|a|+|n|, 1e-6) of each
+	// weight, tiny gradients being compared absolutely
+	Error [][][]float64
+	// MaxError is the largest relative error
+	MaxError float64
+}
+
+// CheckGradients computes the gradient of the loss of n over examples with
+// respect to every weight, by the backpropagation of the trainers and by
+// central differences of step epsilon, 1e-6 by default. The analytic
+// gradient of MSE is scaled by 2/K for K outputs, as the trainers drop the
+// constant of its derivative. Weights of n are restored afterwards.
+func CheckGradients(n *deep.Neural, examples Examples, epsilon float64) *GradientCheck {
+	epsilon = fparam(epsilon, 1e-6)
+	g := &GradientCheck{
+		Analytic: analyticGradients(n, examples),
+		Numeric:  make([][][]float64, len(n.Layers)),
+		Error:    make([][][]float64, len(n.Layers)),
+	}
+	loss := deep.GetLoss(n.Config.Loss)
+	for i, l := range n.Layers {
+		g.Numeric[i] = make([][]float64, len(l.Neurons))
+		g.Error[i] = make([][]float64, len(l.Neurons))
+		for j, neuron := range l.Neurons {
+			g.Numeric[i][j] = make([]float64, len(neuron.In))
+			g.Error[i][j] = make([]float64, len(neuron.In))
+			for k, s := range neuron.In {
+				w := s.Weight
+				s.Weight = w + epsilon
+				plus := totalLoss(n, loss, examples)
+				s.Weight = w - epsilon
+				minus := totalLoss(n, loss, examples)
+				s.Weight = w
+
+				a, num := g.Analytic[i][j][k], (plus-minus)/(2*epsilon)
+				g.Numeric[i][j][k] = num
+				g.Error[i][j][k] = math.Abs(a-num) / math.Max(math.Abs(a)+math.Abs(num), 1e-6)
+				g.MaxError = math.Max(g.MaxError, g.Error[i][j][k])
+			}
+		}
+	}
+	return g
+}
+
+// analyticGradients averages the gradients accumulated by the batch
+// trainer over examples
+func analyticGradients(n *deep.Neural, examples Examples) [][][]float64 {
+	t := &BatchTrainer{internalb: newBatchTraining(n, 1)}
+	for _, e := range examples {
+		n.Forward(e.Input)
+		t.calculateDeltas(n, e.Response, 0)
+	}
+
+	scale := 1 / float64(len(examples))
+	if n.Config.Loss == deep.LossMeanSquared {
+		scale *= 2 / float64(len(n.Layers[len(n.Layers)-1].Neurons))
+	}
+	res := make([][][]float64, len(n.Layers))
+	for i, l := range n.Layers {
+		res[i] = make([][]float64, len(l.Neurons))
+		var idx int
+		for j, neuron := range l.Neurons {
+			res[i][j] = make([]float64, len(neuron.In))
+			for k := range neuron.In {
+				res[i][j][k] = t.partialDeltas[0][i][idx] * scale
+				idx++
+			}
+		}
+	}
+	return res
+}
+
+func totalLoss(n *deep.Neural, loss deep.Loss, examples Examples) float64 {
+	estimates := make([][]float64, len(examples))
+	ideals := make([][]float64, len(examples))
+	for i, e := range examples {
+		estimates[i], ideals[i] = output(n, e.Input), e.Response
+	}
+	return loss.F(estimates, ideals)
+}
+
+// String lists the weights whose relative error exceeds 1e-4
+func (g *GradientCheck) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "max relative error %.3g\n", g.MaxError)
+	for i := range g.Error {
+		for j := range g.Error[i] {
+			for k, e := range g.Error[i][j] {
+				if e > 1e-4 {
+					fmt.Fprintf(&b, "layer %d neuron %d input %d: analytic %.6g numeric %.6g error %.3g\n",
+						i, j, k, g.Analytic[i][j][k], g.Numeric[i][j][k], e)
+				}
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package training
+
+import (
+	"math/rand"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func gradcheckExamples(mode deep.Mode, inputs, outputs, size int) Examples {
+	examples := make(Examples, size)
+	for i := range examples {
+		input := make([]float64, inputs)
+		for j := range input {
+			input[j] = rand.Float64()*2 - 1
+		}
+		response := make([]float64, outputs)
+		switch mode {
+		case deep.ModeMultiClass:
+			response[rand.Intn(outputs)] = 1
+		case deep.ModeBinary, deep.ModeMultiLabel:
+			for j := range response {
+				response[j] = float64(rand.Intn(2))
+			}
+		default:
+			for j := range response {
+				response[j] = rand.Float64()
+			}
+		}
+		examples[i] = Example{Input: input, Response: response}
+	}
+	return examples
+}
+
+func Test_CheckGradients(t *testing.T) {
+	rand.Seed(0)
+	losses := map[deep.Mode][]deep.LossType{
+		deep.ModeDefault:    {deep.LossMeanSquared},
+		deep.ModeRegression: {deep.LossMeanSquared},
+		deep.ModeBinary:     {deep.LossBinaryCrossEntropy, deep.LossMeanSquared},
+		deep.ModeMultiClass: {deep.LossCrossEntropy, deep.LossMeanSquared},
+		deep.ModeMultiLabel: {deep.LossBinaryCrossEntropy, deep.LossMeanSquared},
+	}
+	activations := []deep.ActivationType{deep.ActivationSigmoid, deep.ActivationTanh, deep.ActivationReLU, deep.ActivationLinear}
+	for mode, ll := range losses {
+		for _, loss := range ll {
+			for _, act := range activations {
+				n := deep.NewNeural(&deep.Config{
+					Inputs:     3,
+					Layout:     []int{4, 3, 3},
+					Activation: act,
+					Mode:       mode,
+					Loss:       loss,
+					Weight:     deep.NewNormal(1, 0),
+					Bias:       true,
+				})
+				weights := n.Weights()
+				g := CheckGradients(n, gradcheckExamples(mode, 3, 3, 5), 0)
+				assert.True(t, g.MaxError < 1e-4, "mode %d %s activation %d: %s", mode, loss, act, g)
+				assert.Equal(t, weights, n.Weights())
+			}
+		}
+	}
+}
+
+func Test_CheckGradientsDefaultLoss(t *testing.T) {
+	rand.Seed(0)
+	for _, mode := range []deep.Mode{deep.ModeDefault, deep.ModeRegression, deep.ModeBinary, deep.ModeMultiClass, deep.ModeMultiLabel} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{3, 2},
+			Activation: deep.ActivationTanh,
+			Mode:       mode,
+			Weight:     deep.NewNormal(1, 0),
+			Bias:       true,
+		})
+		g := CheckGradients(n, gradcheckExamples(mode, 2, 2, 4), 0)
+		assert.True(t, g.MaxError < 1e-4, "mode %d: %s", mode, g)
+	}
+}
+
+func Test_CheckGradientsMismatch(t *testing.T) {
+	rand.Seed(0)
+	// CE deltas assume softmax outputs
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 2},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeMultiLabel,
+		Loss:       deep.LossCrossEntropy,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	examples := gradcheckExamples(deep.ModeMultiClass, 2, 2, 4)
+	g := CheckGradients(n, examples, 0)
+	assert.True(t, g.MaxError > 1e-2)
+	assert.Contains(t, g.String(), "layer 1 neuron 0 input 0")
+}
@@ -0,0 +1,121 @@
+package training
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Record holds the state of training at the end of an epoch
+type Record struct {
+	Epoch int     `json:"epoch"`
+	Loss  float64 `json:"loss"`
+	// Validation holds the validation loss under "loss" along with every
+	// validation metric, and is nil without validation examples
+	Validation   map[string]float64 `json:"validation,omitempty"`
+	LearningRate float64            `json:"learning_rate"`
+	// Elapsed is the wall-clock time since the start of training in seconds
+	Elapsed float64 `json:"elapsed"`
+}
+
+// History records the progress of training per epoch. Trainers start a
+// new History on every run, so that one returned earlier keeps the
+// records of its own run.
+type History struct {
+	NopCallback
+	Records []Record
+}
+
+// EpochEnd records an epoch
+func (h *History) EpochEnd(p *Progress) {
+	r := Record{
+		Epoch:        p.Epoch,
+		Loss:         p.Loss,
+		LearningRate: p.LearningRate,
+		Elapsed:      p.Elapsed.Seconds(),
+	}
+	if p.Metrics != nil {
+		r.Validation = map[string]float64{"loss": p.ValidationLoss}
+		for k, v := range p.Metrics {
+			r.Validation[k] = v
+		}
+	}
+	h.Records = append(h.Records, r)
+}
+
+// Series returns the training loss per epoch for "loss", and a validation
+// value per epoch for "val_" followed by its name
+func (h *History) Series(name string) []float64 {
+	res := make([]float64, len(h.Records))
+	for i, r := range h.Records {
+		if name == "loss" {
+			res[i] = r.Loss
+		} else if len(name) > 4 && name[:4] == "val_" {
+			res[i] = r.Validation[name[4:]]
+		}
+	}
+	return res
+}
+
+// WriteJSON writes the history as a JSON array of records
+func (h *History) WriteJSON(w io.Writer) error {
+	records := h.Records
+	if records == nil {
+		records = []Record{}
+	}
+	return json.NewEncoder(w).Encode(records)
+}
+
+// WriteCSV writes the history as CSV with a header row, and a val_ column
+// per validation value
+func (h *History) WriteCSV(w io.Writer) error {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range h.Records {
+		for k := range r.Validation {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// The validation loss goes first
+		if names[i] == "loss" || names[j] == "loss" {
+			return names[i] == "loss"
+		}
+		return names[i] < names[j]
+	})
+
+	cw := csv.NewWriter(w)
+	header := []string{"epoch", "loss"}
+	for _, name := range names {
+		header = append(header, "val_"+name)
+	}
+	header = append(header, "learning_rate", "elapsed")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range h.Records {
+		row := []string{strconv.Itoa(r.Epoch), formatFloat(r.Loss)}
+		for _, name := range names {
+			var v string
+			if value, ok := r.Validation[name]; ok {
+				v = formatFloat(value)
+			}
+			row = append(row, v)
+		}
+		row = append(row, formatFloat(r.LearningRate), formatFloat(r.Elapsed))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
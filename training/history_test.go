@@ -0,0 +1,56 @@
+package training
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_History(t *testing.T) {
+	data := callbackData()
+	trainer := NewBatchTrainer(NewAdam(0.1, 0, 0, 0), 0, 2, 1)
+	trainer.Train(callbackNet(), data, data, 3)
+
+	h := trainer.History()
+	assert.Len(t, h.Records, 3)
+	for i, r := range h.Records {
+		assert.Equal(t, i+1, r.Epoch)
+		assert.True(t, r.Loss > 0)
+		assert.Contains(t, r.Validation, "loss")
+		assert.Equal(t, 0.1, r.LearningRate)
+	}
+	assert.Equal(t, h.Records[2].Validation["loss"], h.Series("val_loss")[2])
+	assert.Equal(t, h.Records[1].Loss, h.Series("loss")[1])
+
+	// Every run starts a new history, leaving earlier ones intact
+	trainer.Train(callbackNet(), data, nil, 2)
+	assert.Len(t, h.Records, 3)
+	assert.Len(t, trainer.History().Records, 2)
+	assert.Nil(t, trainer.History().Records[0].Validation)
+}
+
+func Test_HistoryOutput(t *testing.T) {
+	h := &History{}
+	h.EpochEnd(&Progress{Epoch: 1, Loss: 0.5, LearningRate: 0.1, Elapsed: time.Second, ValidationLoss: 0.75, Metrics: map[string]float64{"accuracy": 0.25}})
+	h.EpochEnd(&Progress{Epoch: 2, Loss: 0.25, LearningRate: 0.1, Elapsed: 2 * time.Second})
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteCSV(&buf))
+	assert.Equal(t, "epoch,loss,val_loss,val_accuracy,learning_rate,elapsed\n"+
+		"1,0.5,0.75,0.25,0.1,1\n"+
+		"2,0.25,,,0.1,2\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, h.WriteJSON(&buf))
+	var records []Record
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+	assert.Equal(t, h.Records, records)
+	assert.Contains(t, buf.String(), `"validation":{"accuracy":0.25,"loss":0.75}`)
+
+	buf.Reset()
+	assert.NoError(t, (&History{}).WriteJSON(&buf))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -0,0 +1,409 @@
+package training
+
+import (
+	"math"
+	"sort"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Metric evaluates predictions against their targets. Classification
+// metrics take the argmax of each row in ModeMultiClass, and threshold
+// every output in the other modes.
+type Metric interface {
+	Name() string
+	Compute(mode deep.Mode, predictions, targets [][]float64) float64
+}
+
+// DefaultMetrics returns the metrics reported when none are configured
+func DefaultMetrics(mode deep.Mode) []Metric {
+	switch mode {
+	case deep.ModeMultiClass, deep.ModeBinary, deep.ModeMultiLabel:
+		return []Metric{NewAccuracy(0)}
+	}
+	return nil
+}
+
+// Averaging determines how per-class scores are combined
+type Averaging int
+
+const (
+	// Micro pools the counts of all classes
+	Micro Averaging = 0
+	// Macro averages the scores of every class
+	Macro Averaging = 1
+)
+
+func (a Averaging) String() string {
+	if a == Macro {
+		return "macro"
+	}
+	return "micro"
+}
+
+// Accuracy is the fraction of correctly classified outputs
+type Accuracy struct {
+	threshold float64
+}
+
+// NewAccuracy returns an accuracy metric, with a decision threshold of
+// 0.5 by default
+func NewAccuracy(threshold float64) *Accuracy {
+	return &Accuracy{threshold: fparam(threshold, 0.5)}
+}
+
+// Name returns the name of the metric
+func (m *Accuracy) Name() string { return "accuracy" }
+
+// Compute computes accuracy
+func (m *Accuracy) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	if mode == deep.ModeMultiClass {
+		var correct int
+		for i := range predictions {
+			if deep.ArgMax(predictions[i]) == deep.ArgMax(targets[i]) {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(predictions))
+	}
+	var correct, total int
+	for i := range predictions {
+		for j := range predictions[i] {
+			if (predictions[i][j] >= m.threshold) == (targets[i][j] >= 0.5) {
+				correct++
+			}
+			total++
+		}
+	}
+	return float64(correct) / float64(total)
+}
+
+// confusion holds true positive, false positive and false negative
+// counts per class
+type confusion struct {
+	tp, fp, fn []float64
+}
+
+func newConfusion(mode deep.Mode, threshold float64, predictions, targets [][]float64) confusion {
+	var classes int
+	if len(predictions) > 0 {
+		classes = len(predictions[0])
+	}
+	c := confusion{
+		tp: make([]float64, classes),
+		fp: make([]float64, classes),
+		fn: make([]float64, classes),
+	}
+	for i := range predictions {
+		for j := 0; j < classes; j++ {
+			var predicted, actual bool
+			if mode == deep.ModeMultiClass {
+				predicted, actual = deep.ArgMax(predictions[i]) == j, deep.ArgMax(targets[i]) == j
+			} else {
+				predicted, actual = predictions[i][j] >= threshold, targets[i][j] >= 0.5
+			}
+			switch {
+			case predicted && actual:
+				c.tp[j]++
+			case predicted:
+				c.fp[j]++
+			case actual:
+				c.fn[j]++
+			}
+		}
+	}
+	return c
+}
+
+// score combines per-class counts into a single score
+func (c confusion) score(avg Averaging, f func(tp, fp, fn float64) float64) float64 {
+	if avg == Macro {
+		var sum float64
+		for j := range c.tp {
+			sum += f(c.tp[j], c.fp[j], c.fn[j])
+		}
+		return sum / float64(len(c.tp))
+	}
+	var tp, fp, fn float64
+	for j := range c.tp {
+		tp, fp, fn = tp+c.tp[j], fp+c.fp[j], fn+c.fn[j]
+	}
+	return f(tp, fp, fn)
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func precision(tp, fp, fn float64) float64 { return ratio(tp, tp+fp) }
+func recall(tp, fp, fn float64) float64    { return ratio(tp, tp+fn) }
+func f1(tp, fp, fn float64) float64        { return ratio(2*tp, 2*tp+fp+fn) }
+
+// Precision is the fraction of positive predictions that are correct
+type Precision struct {
+	avg       Averaging
+	threshold float64
+}
+
+// NewPrecision returns a precision metric, with a decision threshold of
+// 0.5 by default
+func NewPrecision(avg Averaging, threshold float64) *Precision {
+	return &Precision{avg: avg, threshold: fparam(threshold, 0.5)}
+}
+
+// Name returns the name of the metric
+func (m *Precision) Name() string { return "precision_" + m.avg.String() }
+
+// Compute computes precision
+func (m *Precision) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return newConfusion(mode, m.threshold, predictions, targets).score(m.avg, precision)
+}
+
+// Recall is the fraction of positives that are predicted as such
+type Recall struct {
+	avg       Averaging
+	threshold float64
+}
+
+// NewRecall returns a recall metric, with a decision threshold of 0.5 by
+// default
+func NewRecall(avg Averaging, threshold float64) *Recall {
+	return &Recall{avg: avg, threshold: fparam(threshold, 0.5)}
+}
+
+// Name returns the name of the metric
+func (m *Recall) Name() string { return "recall_" + m.avg.String() }
+
+// Compute computes recall
+func (m *Recall) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return newConfusion(mode, m.threshold, predictions, targets).score(m.avg, recall)
+}
+
+// F1 is the harmonic mean of precision and recall
+type F1 struct {
+	avg       Averaging
+	threshold float64
+}
+
+// NewF1 returns an F1 metric, with a decision threshold of 0.5 by default
+func NewF1(avg Averaging, threshold float64) *F1 {
+	return &F1{avg: avg, threshold: fparam(threshold, 0.5)}
+}
+
+// Name returns the name of the metric
+func (m *F1) Name() string { return "f1_" + m.avg.String() }
+
+// Compute computes F1
+func (m *F1) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return newConfusion(mode, m.threshold, predictions, targets).score(m.avg, f1)
+}
+
+// ROCAUC is the area under the ROC curve, macro averaged over outputs.
+// Outputs without both positive and negative targets are skipped, and NaN
+// is returned if none remain.
+type ROCAUC struct{}
+
+// Name returns the name of the metric
+func (m ROCAUC) Name() string { return "roc_auc" }
+
+// Compute computes ROC-AUC
+func (m ROCAUC) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return averageOutputs(predictions, targets, rocAUC)
+}
+
+// PRAUC is the area under the precision-recall curve, computed as average
+// precision and macro averaged over outputs
+type PRAUC struct{}
+
+// Name returns the name of the metric
+func (m PRAUC) Name() string { return "pr_auc" }
+
+// Compute computes PR-AUC
+func (m PRAUC) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	return averageOutputs(predictions, targets, averagePrecision)
+}
+
+type scored struct {
+	score    float64
+	positive bool
+}
+
+// averageOutputs averages f over every output that has both positive and
+// negative targets
+func averageOutputs(predictions, targets [][]float64, f func([]scored, int) float64) float64 {
+	if len(predictions) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	var count int
+	for j := range predictions[0] {
+		s := make([]scored, len(predictions))
+		var positives int
+		for i := range predictions {
+			s[i] = scored{predictions[i][j], targets[i][j] >= 0.5}
+			if s[i].positive {
+				positives++
+			}
+		}
+		if positives == 0 || positives == len(s) {
+			continue
+		}
+		sort.Slice(s, func(a, b int) bool { return s[a].score > s[b].score })
+		sum += f(s, positives)
+		count++
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// rocAUC computes the probability that a positive is scored above a
+// negative from scores sorted in descending order, counting ties as half
+func rocAUC(s []scored, positives int) float64 {
+	negatives := len(s) - positives
+	var sum float64
+	var seen int
+	for i := 0; i < len(s); {
+		var p, n int
+		j := i
+		for ; j < len(s) && s[j].score == s[i].score; j++ {
+			if s[j].positive {
+				p++
+			} else {
+				n++
+			}
+		}
+		seen += n
+		sum += float64(p) * (float64(negatives-seen) + float64(n)/2)
+		i = j
+	}
+	return sum / float64(positives*negatives)
+}
+
+// averagePrecision sums precision at every distinct threshold of scores
+// sorted in descending order, weighted by the increase in recall
+func averagePrecision(s []scored, positives int) float64 {
+	var tp, fp, sum float64
+	for i := 0; i < len(s); {
+		j := i
+		var gained float64
+		for ; j < len(s) && s[j].score == s[i].score; j++ {
+			if s[j].positive {
+				gained++
+			} else {
+				fp++
+			}
+		}
+		tp += gained
+		sum += gained / float64(positives) * tp / (tp + fp)
+		i = j
+	}
+	return sum
+}
+
+// LogLoss is the cross entropy of the predictions, categorical in
+// ModeMultiClass and binary otherwise
+type LogLoss struct{}
+
+// Name returns the name of the metric
+func (m LogLoss) Name() string { return "log_loss" }
+
+// Compute computes the log loss
+func (m LogLoss) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	const eps = 1e-15
+	var sum float64
+	var count int
+	for i := range predictions {
+		for j, p := range predictions[i] {
+			p = math.Max(eps, math.Min(1-eps, p))
+			y := targets[i][j]
+			if mode == deep.ModeMultiClass {
+				sum -= y * math.Log(p)
+				continue
+			}
+			sum -= y*math.Log(p) + (1-y)*math.Log(1-p)
+			count++
+		}
+	}
+	if mode == deep.ModeMultiClass {
+		return sum / float64(len(predictions))
+	}
+	return sum / float64(count)
+}
+
+// MAE is the mean absolute error over all outputs
+type MAE struct{}
+
+// Name returns the name of the metric
+func (m MAE) Name() string { return "mae" }
+
+// Compute computes the mean absolute error
+func (m MAE) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	var sum float64
+	var count int
+	for i := range predictions {
+		for j, p := range predictions[i] {
+			sum += math.Abs(p - targets[i][j])
+			count++
+		}
+	}
+	return sum / float64(count)
+}
+
+// RMSE is the root mean squared error over all outputs
+type RMSE struct{}
+
+// Name returns the name of the metric
+func (m RMSE) Name() string { return "rmse" }
+
+// Compute computes the root mean squared error
+func (m RMSE) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	var sum float64
+	var count int
+	for i := range predictions {
+		for j, p := range predictions[i] {
+			sum += (p - targets[i][j]) * (p - targets[i][j])
+			count++
+		}
+	}
+	return math.Sqrt(sum / float64(count))
+}
+
+// R2 is the coefficient of determination, averaged over outputs
+type R2 struct{}
+
+// Name returns the name of the metric
+func (m R2) Name() string { return "r2" }
+
+// Compute computes R²
+func (m R2) Compute(mode deep.Mode, predictions, targets [][]float64) float64 {
+	if len(predictions) == 0 {
+		return math.NaN()
+	}
+	outputs := len(predictions[0])
+	var sum float64
+	for j := 0; j < outputs; j++ {
+		var mean float64
+		for i := range targets {
+			mean += targets[i][j]
+		}
+		mean /= float64(len(targets))
+
+		var residual, total float64
+		for i := range targets {
+			residual += (targets[i][j] - predictions[i][j]) * (targets[i][j] - predictions[i][j])
+			total += (targets[i][j] - mean) * (targets[i][j] - mean)
+		}
+		switch {
+		case total > 0:
+			sum += 1 - residual/total
+		case residual == 0:
+			sum++
+		}
+	}
+	return sum / float64(outputs)
+}
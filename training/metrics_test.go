@@ -0,0 +1,163 @@
+package training
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BinaryMetrics(t *testing.T) {
+	predictions := [][]float64{{0.9}, {0.8}, {0.3}, {0.6}, {0.2}, {0.1}}
+	targets := [][]float64{{1}, {1}, {1}, {0}, {0}, {0}}
+	mode := deep.ModeBinary
+
+	// tp = 2, fp = 1, fn = 1, tn = 2
+	assert.InDelta(t, 4.0/6, NewAccuracy(0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 5.0/6, NewAccuracy(0.7).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 2.0/3, NewPrecision(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 2.0/3, NewRecall(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 2.0/3, NewF1(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 1.0, NewPrecision(Micro, 0.7).Compute(mode, predictions, targets), 1e-12)
+
+	// 8 of 9 positive-negative pairs are ordered correctly
+	assert.InDelta(t, 8.0/9, ROCAUC{}.Compute(mode, predictions, targets), 1e-12)
+	// Precision at each positive: 1, 1, 3/4
+	assert.InDelta(t, (1+1+0.75)/3, PRAUC{}.Compute(mode, predictions, targets), 1e-12)
+
+	expected := -(math.Log(0.9) + math.Log(0.8) + math.Log(0.3) + math.Log(0.4) + math.Log(0.8) + math.Log(0.9)) / 6
+	assert.InDelta(t, expected, LogLoss{}.Compute(mode, predictions, targets), 1e-12)
+
+	// Ties count as half
+	assert.InDelta(t, 0.5, ROCAUC{}.Compute(mode, [][]float64{{0.5}, {0.5}}, [][]float64{{1}, {0}}), 1e-12)
+	assert.True(t, math.IsNaN(ROCAUC{}.Compute(mode, [][]float64{{0.5}}, [][]float64{{1}})))
+}
+
+func Test_MultiClassMetrics(t *testing.T) {
+	predictions := [][]float64{
+		{0.7, 0.2, 0.1},
+		{0.1, 0.8, 0.1},
+		{0.2, 0.5, 0.3},
+		{0.3, 0.3, 0.4},
+	}
+	targets := [][]float64{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0, 0, 1},
+		{0, 0, 1},
+	}
+	mode := deep.ModeMultiClass
+
+	assert.InDelta(t, 0.75, NewAccuracy(0).Compute(mode, predictions, targets), 1e-12)
+	// Micro averages equal accuracy for single label classification
+	assert.InDelta(t, 0.75, NewPrecision(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 0.75, NewF1(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	// Per class precision 1, 1/2, 1 and recall 1, 1, 1/2
+	assert.InDelta(t, 2.5/3, NewPrecision(Macro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 2.5/3, NewRecall(Macro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, (1+2.0/3+2.0/3)/3, NewF1(Macro, 0).Compute(mode, predictions, targets), 1e-12)
+
+	expected := -(math.Log(0.7) + math.Log(0.8) + math.Log(0.3) + math.Log(0.4)) / 4
+	assert.InDelta(t, expected, LogLoss{}.Compute(mode, predictions, targets), 1e-12)
+	assert.Equal(t, "f1_macro", NewF1(Macro, 0).Name())
+}
+
+func Test_MultiLabelMetrics(t *testing.T) {
+	predictions := [][]float64{{0.9, 0.2}, {0.6, 0.7}}
+	targets := [][]float64{{1, 0}, {0, 1}}
+	mode := deep.ModeMultiLabel
+
+	assert.InDelta(t, 0.75, NewAccuracy(0).Compute(mode, predictions, targets), 1e-12)
+	// Label 0: tp 1, fp 1; label 1: tp 1
+	assert.InDelta(t, 2.0/3, NewPrecision(Micro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 0.75, NewPrecision(Macro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 1.0, NewRecall(Macro, 0).Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, 1.0, ROCAUC{}.Compute(mode, predictions, targets), 1e-12)
+}
+
+func Test_RegressionMetrics(t *testing.T) {
+	predictions := [][]float64{{1, 10}, {2, 20}, {4, 30}}
+	targets := [][]float64{{1, 10}, {3, 20}, {3, 30}}
+	mode := deep.ModeRegression
+
+	assert.InDelta(t, 2.0/6, MAE{}.Compute(mode, predictions, targets), 1e-12)
+	assert.InDelta(t, math.Sqrt(2.0/6), RMSE{}.Compute(mode, predictions, targets), 1e-12)
+	// First output: residual 2, total 8/3
+	assert.InDelta(t, (1-2/(8.0/3)+1)/2, R2{}.Compute(mode, predictions, targets), 1e-12)
+}
+
+func Test_PrinterFormats(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs: 1, Layout: []int{1}, Mode: deep.ModeBinary,
+		Activation: deep.ActivationSigmoid, Weight: deep.NewNormal(1, 0),
+	})
+	progress := &Progress{
+		Network:        n,
+		Epoch:          1,
+		Elapsed:        time.Second,
+		ValidationLoss: 0.5,
+		Metrics:        map[string]float64{"accuracy": 0.75, "roc_auc": 0.25},
+	}
+	metrics := []Metric{NewAccuracy(0), ROCAUC{}}
+
+	var buf bytes.Buffer
+	p := NewWriterPrinter(&buf, FormatCSV)
+	p.verbosity, p.metrics = 1, metrics
+	p.TrainBegin(progress)
+	p.EpochEnd(progress)
+	assert.Equal(t, "epoch,elapsed,loss,accuracy,roc_auc\n1,1,0.5,0.75,0.25\n", buf.String())
+
+	buf.Reset()
+	p = NewWriterPrinter(&buf, FormatJSON)
+	p.verbosity, p.metrics = 1, metrics
+	p.rate = func() float64 { return 0.1 }
+	p.TrainBegin(progress)
+	p.EpochEnd(progress)
+	assert.Equal(t, `{"accuracy":0.75,"elapsed":1,"epoch":1,"learning_rate":0.1,"loss":0.5,"roc_auc":0.25}`+"\n", buf.String())
+
+	buf.Reset()
+	p = NewWriterPrinter(&buf, FormatTable)
+	p.verbosity, p.metrics = 1, metrics
+	p.TrainBegin(progress)
+	p.EpochEnd(progress)
+	assert.True(t, strings.HasPrefix(buf.String(), "Epochs"))
+	assert.Contains(t, buf.String(), "Roc_auc")
+
+	// Metrics without a name are printed under an empty header
+	buf.Reset()
+	p = NewWriterPrinter(&buf, FormatTable)
+	p.verbosity, p.metrics = 1, []Metric{unnamedMetric{}}
+	p.TrainBegin(progress)
+	p.EpochEnd(progress)
+	assert.True(t, strings.HasPrefix(buf.String(), "Epochs"))
+}
+
+type unnamedMetric struct{}
+
+func (unnamedMetric) Name() string { return "" }
+
+func (unnamedMetric) Compute(mode deep.Mode, predictions, targets [][]float64) float64 { return 0 }
+
+func Test_TrainerMetrics(t *testing.T) {
+	data := callbackData()
+	var buf bytes.Buffer
+
+	trainer := NewTrainer(NewSGD(0.1, 0, 0, false), 1)
+	trainer.SetMetrics(NewF1(Macro, 0), LogLoss{})
+	trainer.SetPrinter(NewWriterPrinter(&buf, FormatCSV))
+	trainer.Train(callbackNet(), data, data, 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "epoch,elapsed,loss,f1_macro,log_loss", lines[0])
+	assert.Contains(t, trainer.History().Records[1].Validation, "f1_macro")
+
+	// Binary models report accuracy by default
+	trainer = NewTrainer(NewSGD(0.1, 0, 0, false), 0)
+	trainer.Train(callbackNet(), data, data, 1)
+	assert.Contains(t, trainer.History().Records[0].Validation, "accuracy")
+}
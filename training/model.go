@@ -1,6 +1,10 @@
 package training
 
-import "math/rand"
+import (
+	"math/rand"
+
+	deep "github.com/patrikeh/go-deep"
+)
 
 // Example is an input-target pair
 type Example struct {
@@ -11,6 +15,31 @@ type Example struct {
 // Examples is a set of input-output pairs
 type Examples []Example
 
+// Inputs returns the inputs of all examples
+func (e Examples) Inputs() [][]float64 {
+	res := make([][]float64, len(e))
+	for i := range e {
+		res[i] = e[i].Input
+	}
+	return res
+}
+
+// Fit fits p on the inputs of the examples, and returns the examples with
+// their inputs transformed
+func (e Examples) Fit(p deep.Preprocessor) Examples {
+	p.Fit(e.Inputs())
+	return e.Transform(p)
+}
+
+// Transform returns the examples with inputs transformed by p
+func (e Examples) Transform(p deep.Preprocessor) Examples {
+	res := make(Examples, len(e))
+	for i := range e {
+		res[i] = Example{Input: p.Transform(e[i].Input), Response: e[i].Response}
+	}
+	return res
+}
+
 // Shuffle shuffles slice in-place
 func (e Examples) Shuffle() {
 	for i := range e {
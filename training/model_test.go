@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"testing"
 
+	deep "github.com/patrikeh/go-deep"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +38,23 @@ func Test_Split(t *testing.T) {
 	assert.InEpsilon(t, len(a), 50, 0.1)
 	assert.InEpsilon(t, len(b), 50, 0.1)
 }
+
+func Test_Preprocess(t *testing.T) {
+	train := Examples{{[]float64{0, 10}, []float64{0}}, {[]float64{2, 30}, []float64{1}}}
+	test := Examples{{[]float64{1, 40}, []float64{1}}}
+
+	scaler := deep.NewMinMaxScaler()
+	scaled := train.Fit(scaler)
+	assert.Equal(t, []float64{1, 1}, scaled[1].Input)
+	assert.Equal(t, []float64{0.5, 1.5}, test.Transform(scaler)[0].Input)
+	// The examples are left in place
+	assert.Equal(t, []float64{2, 30}, train[1].Input)
+
+	// Trainers see preprocessed examples, Predict takes raw features
+	n := deep.NewNeural(&deep.Config{Inputs: 2, Layout: []int{1}, Mode: deep.ModeBinary, Weight: deep.NewNormal(1, 0)})
+	NewTrainer(NewSGD(0.1, 0, 0, false), 0).Train(n, scaled, scaled, 1)
+	expected, loss := n.Predict(scaled[1].Input), crossValidate(n, scaled)
+	n.Preprocessing = deep.Pipeline{scaler}
+	assert.Equal(t, expected, n.Predict(train[1].Input))
+	assert.Equal(t, loss, crossValidate(n, scaled))
+}
@@ -0,0 +1,108 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// parameters holds a weight and gradient vector per layer, laid out
+// in the same order as deep.Neural.Weights
+type parameters struct {
+	weights   [][]float64
+	gradients [][]float64
+}
+
+func newParameters(layers []*deep.Layer) *parameters {
+	p := &parameters{
+		weights:   make([][]float64, len(layers)),
+		gradients: make([][]float64, len(layers)),
+	}
+	for i, l := range layers {
+		var size int
+		for _, n := range l.Neurons {
+			size += len(n.In)
+		}
+		p.weights[i] = make([]float64, size)
+		p.gradients[i] = make([]float64, size)
+	}
+	return p
+}
+
+// update applies solver to the weights of n layer by layer, and resets
+// the gradients
+func (p *parameters) update(n *deep.Neural, solver VectorSolver, it int) {
+	solver.Step(it)
+
+	var offset int
+	for i, l := range n.Layers {
+		weights, gradients := p.weights[i], p.gradients[i]
+		var k int
+		for _, n := range l.Neurons {
+			for _, s := range n.In {
+				weights[k] = s.Weight
+				k++
+			}
+		}
+
+		solver.UpdateVector(weights, gradients, offset)
+
+		k = 0
+		for _, n := range l.Neurons {
+			for _, s := range n.In {
+				s.Weight = weights[k]
+				k++
+			}
+		}
+		for k := range gradients {
+			gradients[k] = 0
+		}
+		offset += len(weights)
+	}
+}
+
+// Clipping bounds the accumulated gradients before every update. Value
+// clips each gradient to [-Value, Value], Norm rescales the gradients so
+// that their global L2 norm is at most Norm. Zero values disable clipping.
+type Clipping struct {
+	Value float64
+	Norm  float64
+}
+
+// clip applies c to the gradients and returns their global L2 norm
+// before clipping
+func (p *parameters) clip(c Clipping) float64 {
+	norm := p.norm()
+	if c.Value > 0 {
+		for _, l := range p.gradients {
+			for k, g := range l {
+				l[k] = math.Max(-c.Value, math.Min(c.Value, g))
+			}
+		}
+	}
+	if c.Norm > 0 {
+		clipped := norm
+		if c.Value > 0 {
+			clipped = p.norm()
+		}
+		if clipped > c.Norm {
+			scale := c.Norm / clipped
+			for _, l := range p.gradients {
+				for k := range l {
+					l[k] *= scale
+				}
+			}
+		}
+	}
+	return norm
+}
+
+func (p *parameters) norm() float64 {
+	var sum float64
+	for _, l := range p.gradients {
+		for _, g := range l {
+			sum += g * g
+		}
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,71 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Clip(t *testing.T) {
+	p := &parameters{gradients: [][]float64{{3, -4}, {0}}}
+	assert.Equal(t, 5.0, p.clip(Clipping{}))
+	assert.Equal(t, [][]float64{{3, -4}, {0}}, p.gradients)
+
+	assert.Equal(t, 5.0, p.clip(Clipping{Value: 2}))
+	assert.Equal(t, [][]float64{{2, -2}, {0}}, p.gradients)
+
+	p.gradients = [][]float64{{3, -4}, {0}}
+	assert.Equal(t, 5.0, p.clip(Clipping{Norm: 1}))
+	assert.InDeltaSlice(t, []float64{0.6, -0.8}, p.gradients[0], 1e-12)
+
+	// Norm clipping applies to the value clipped gradients
+	p.gradients = [][]float64{{3, -4}, {0}}
+	p.clip(Clipping{Value: 1, Norm: 1})
+	assert.InDeltaSlice(t, []float64{math.Sqrt(0.5), -math.Sqrt(0.5)}, p.gradients[0], 1e-12)
+
+	// Gradients within bounds are untouched
+	p.gradients = [][]float64{{0.1, 0.2}}
+	p.clip(Clipping{Value: 1, Norm: 1})
+	assert.Equal(t, [][]float64{{0.1, 0.2}}, p.gradients)
+}
+
+func Test_ClippedTraining(t *testing.T) {
+	data := Examples{}
+	for i := 0; i < 50; i++ {
+		x := float64(i)
+		data = append(data, Example{[]float64{x}, []float64{1000 * x}})
+	}
+
+	for _, trainer := range []interface {
+		Trainer
+		SetClipping(Clipping)
+		GradientNorm() float64
+	}{
+		NewTrainer(NewSGD(0.1, 0, 0, false), 0),
+		NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 10, 2),
+	} {
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     1,
+			Layout:     []int{4, 1},
+			Activation: deep.ActivationReLU,
+			Mode:       deep.ModeRegression,
+			Weight:     deep.NewNormal(0.5, 0),
+			Bias:       true,
+		})
+		trainer.SetClipping(Clipping{Value: 10, Norm: 1})
+		trainer.Train(n, data, nil, 5)
+
+		assert.True(t, trainer.GradientNorm() > 0)
+		for _, l := range n.Weights() {
+			for _, w := range l {
+				for _, v := range w {
+					assert.False(t, math.IsNaN(v) || math.IsInf(v, 0))
+					// Each update moves a weight by at most lr * norm
+					assert.True(t, math.Abs(v) < 100)
+				}
+			}
+		}
+	}
+}
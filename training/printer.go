@@ -1,68 +1,216 @@
 package training
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	deep "github.com/patrikeh/go-deep"
 )
 
-// StatsPrinter prints training progress
+// Format is an output format of StatsPrinter
+type Format int
+
+const (
+	// FormatTable prints an aligned table
+	FormatTable Format = 0
+	// FormatCSV prints comma separated values with a header row
+	FormatCSV Format = 1
+	// FormatJSON prints a JSON object per line
+	FormatJSON Format = 2
+)
+
+// StatsPrinter prints training progress. It is registered as a Callback
+// by the trainers, printing every verbosity epochs.
 type StatsPrinter struct {
-	w *tabwriter.Writer
+	NopCallback
+	w         io.Writer
+	format    Format
+	verbosity int
+	// metrics are printed for every epoch, defaults by mode if nil
+	metrics []Metric
+	// rate reports the current learning rate of a scheduled solver
+	rate func() float64
+	// regularizer adds the weight penalty to the reported loss
+	regularizer *regularizer
 }
 
-// NewStatsPrinter creates a StatsPrinter
+// NewStatsPrinter creates a StatsPrinter printing a table to stdout
 func NewStatsPrinter() *StatsPrinter {
-	return &StatsPrinter{tabwriter.NewWriter(os.Stdout, 16, 0, 3, ' ', 0)}
+	return NewWriterPrinter(os.Stdout, FormatTable)
+}
+
+// NewWriterPrinter creates a StatsPrinter printing to w in format
+func NewWriterPrinter(w io.Writer, format Format) *StatsPrinter {
+	if format == FormatTable {
+		w = tabwriter.NewWriter(w, 16, 0, 3, ' ', 0)
+	}
+	return &StatsPrinter{w: w, format: format}
 }
 
 // Init initializes printer
 func (p *StatsPrinter) Init(n *deep.Neural) {
-	fmt.Fprintf(p.w, "Epochs\tElapsed\tLoss (%s)\t", n.Config.Loss)
-	if n.Config.Mode == deep.ModeMultiClass {
-		fmt.Fprintf(p.w, "Accuracy\t\n---\t---\t---\t---\t\n")
-	} else {
-		fmt.Fprintf(p.w, "\n---\t---\t---\t\n")
+	metrics := p.metricsFor(n)
+	switch p.format {
+	case FormatTable:
+		fmt.Fprintf(p.w, "Epochs\tElapsed\tLoss (%s)\t", n.Config.Loss)
+		columns := 3
+		for _, m := range metrics {
+			name := m.Name()
+			if name != "" {
+				name = strings.ToUpper(name[:1]) + name[1:]
+			}
+			fmt.Fprintf(p.w, "%s\t", name)
+			columns++
+		}
+		if p.rate != nil {
+			fmt.Fprintf(p.w, "LR\t")
+			columns++
+		}
+		fmt.Fprintf(p.w, "\n%s\n", strings.Repeat("---\t", columns))
+	case FormatCSV:
+		header := []string{"epoch", "elapsed", "loss"}
+		for _, m := range metrics {
+			header = append(header, m.Name())
+		}
+		if p.rate != nil {
+			header = append(header, "learning_rate")
+		}
+		p.writeCSV(header)
 	}
 }
 
 // PrintProgress prints the current state of training
 func (p *StatsPrinter) PrintProgress(n *deep.Neural, validation Examples, elapsed time.Duration, iteration int) {
-	fmt.Fprintf(p.w, "%d\t%s\t%.4f\t%s\n",
-		iteration,
-		elapsed.String(),
-		crossValidate(n, validation),
-		formatAccuracy(n, validation))
-	p.w.Flush()
+	loss, metrics := evaluate(n, validation, p.regularizer, p.metricsFor(n))
+	p.print(n, iteration, elapsed, loss, metrics)
 }
 
-func formatAccuracy(n *deep.Neural, validation Examples) string {
-	if n.Config.Mode == deep.ModeMultiClass {
-		return fmt.Sprintf("%.2f\t", accuracy(n, validation))
+// TrainBegin prints the header
+func (p *StatsPrinter) TrainBegin(progress *Progress) {
+	if p.verbosity > 0 {
+		p.Init(progress.Network)
 	}
-	return ""
 }
 
-func accuracy(n *deep.Neural, validation Examples) float64 {
-	correct := 0
-	for _, e := range validation {
-		est := n.Predict(e.Input)
-		if deep.ArgMax(e.Response) == deep.ArgMax(est) {
-			correct++
+// EpochEnd prints the validation loss and metrics every verbosity epochs
+func (p *StatsPrinter) EpochEnd(progress *Progress) {
+	if p.verbosity <= 0 || progress.Epoch%p.verbosity != 0 || progress.Metrics == nil {
+		return
+	}
+	p.print(progress.Network, progress.Epoch, progress.Elapsed, progress.ValidationLoss, progress.Metrics)
+}
+
+func (p *StatsPrinter) print(n *deep.Neural, iteration int, elapsed time.Duration, loss float64, values map[string]float64) {
+	metrics := p.metricsFor(n)
+	switch p.format {
+	case FormatTable:
+		fmt.Fprintf(p.w, "%d\t%s\t%.4f\t", iteration, elapsed.String(), loss)
+		for _, m := range metrics {
+			fmt.Fprintf(p.w, "%.4f\t", values[m.Name()])
+		}
+		if p.rate != nil {
+			fmt.Fprintf(p.w, "%.4g\t", p.rate())
+		}
+		fmt.Fprintln(p.w)
+		p.w.(*tabwriter.Writer).Flush()
+	case FormatCSV:
+		row := []string{fmt.Sprint(iteration), formatFloat(elapsed.Seconds()), formatFloat(loss)}
+		for _, m := range metrics {
+			row = append(row, formatFloat(values[m.Name()]))
 		}
+		if p.rate != nil {
+			row = append(row, formatFloat(p.rate()))
+		}
+		p.writeCSV(row)
+	case FormatJSON:
+		line := map[string]interface{}{
+			"epoch":   iteration,
+			"elapsed": elapsed.Seconds(),
+			"loss":    loss,
+		}
+		for _, m := range metrics {
+			line[m.Name()] = values[m.Name()]
+		}
+		if p.rate != nil {
+			line["learning_rate"] = p.rate()
+		}
+		json.NewEncoder(p.w).Encode(line)
 	}
-	return float64(correct) / float64(len(validation))
 }
 
-func crossValidate(n *deep.Neural, validation Examples) float64 {
-	predictions, responses := make([][]float64, len(validation)), make([][]float64, len(validation))
-	for i := 0; i < len(validation); i++ {
-		predictions[i] = n.Predict(validation[i].Input)
-		responses[i] = validation[i].Response
+func (p *StatsPrinter) writeCSV(record []string) {
+	w := csv.NewWriter(p.w)
+	w.Write(record)
+	w.Flush()
+}
+
+func (p *StatsPrinter) metricsFor(n *deep.Neural) []Metric {
+	if p.metrics != nil {
+		return p.metrics
+	}
+	return DefaultMetrics(n.Config.Mode)
+}
+
+// evaluate returns the loss of n over examples including the penalty of
+// r, and the value of every metric
+func evaluate(n *deep.Neural, examples Examples, r *regularizer, metrics []Metric) (float64, map[string]float64) {
+	predictions, responses := predict(n, examples)
+	values := make(map[string]float64, len(metrics))
+	for _, m := range metrics {
+		values[m.Name()] = m.Compute(n.Config.Mode, predictions, responses)
 	}
+	return deep.GetLoss(n.Config.Loss).F(predictions, responses) + r.penalty(n), values
+}
 
+// Validate returns the loss of n over examples and the value of every
+// metric, DefaultMetrics if nil, as computed by the trainers on their
+// validation examples
+func Validate(n *deep.Neural, examples Examples, metrics []Metric) (float64, map[string]float64) {
+	if metrics == nil {
+		metrics = DefaultMetrics(n.Config.Mode)
+	}
+	return evaluate(n, examples, nil, metrics)
+}
+
+func predict(n *deep.Neural, examples Examples) ([][]float64, [][]float64) {
+	predictions, responses := make([][]float64, len(examples)), make([][]float64, len(examples))
+	for i := 0; i < len(examples); i++ {
+		predictions[i] = output(n, examples[i].Input)
+		responses[i] = examples[i].Response
+	}
+	return predictions, responses
+}
+
+// output computes the prediction for an input already preprocessed, as are
+// all examples seen by the trainers
+func output(n *deep.Neural, input []float64) []float64 {
+	n.Forward(input)
+	out := n.Layers[len(n.Layers)-1].Neurons
+	res := make([]float64, len(out))
+	for i, neuron := range out {
+		res[i] = neuron.Value
+	}
+	return res
+}
+
+// probabilities computes the output for a preprocessed input, calibrated
+// by the calibrator of n if any
+func probabilities(n *deep.Neural, input []float64) []float64 {
+	out := output(n, input)
+	if n.Calibrator != nil {
+		return n.Calibrator.Calibrate(out)
+	}
+	return out
+}
+
+// crossValidate returns the loss of n over validation
+func crossValidate(n *deep.Neural, validation Examples) float64 {
+	predictions, responses := predict(n, validation)
 	return deep.GetLoss(n.Config.Loss).F(predictions, responses)
 }
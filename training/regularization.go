@@ -0,0 +1,123 @@
+package training
+
+import (
+	"math"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Regularization penalizes the weights of a layer by adding
+// L1*sum(|w|) + L2/2*sum(w^2) to the loss. Setting both L1 and L2 gives
+// an elastic net. Bias weights are excluded unless Bias is set.
+// MaxNorm, if positive, rescales the incoming weights of each neuron after
+// every update so that their L2 norm is at most MaxNorm.
+type Regularization struct {
+	L1      float64
+	L2      float64
+	Bias    bool
+	MaxNorm float64
+}
+
+// regularizer holds the regularization of every layer of a network
+type regularizer struct {
+	all    Regularization
+	layers map[int]Regularization
+}
+
+func (r *regularizer) layer(i int) Regularization {
+	if l, ok := r.layers[i]; ok {
+		return l
+	}
+	return r.all
+}
+
+func (r *regularizer) set(i int, reg Regularization) {
+	if r.layers == nil {
+		r.layers = make(map[int]Regularization)
+	}
+	r.layers[i] = reg
+}
+
+// penalty returns the regularization term of the loss of n
+func (r *regularizer) penalty(n *deep.Neural) float64 {
+	if r == nil {
+		return 0
+	}
+	var sum float64
+	for i, l := range n.Layers {
+		reg := r.layer(i)
+		if reg.L1 == 0 && reg.L2 == 0 {
+			continue
+		}
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if s.IsBias && !reg.Bias {
+					continue
+				}
+				sum += reg.L1*math.Abs(s.Weight) + reg.L2/2*s.Weight*s.Weight
+			}
+		}
+	}
+	return sum
+}
+
+// gradients adds the gradient of the regularization term to p, which holds
+// data gradients summed over batchSize examples. The term is scaled alike,
+// so that its strength relative to the mean loss does not depend on the
+// batch size.
+func (r *regularizer) gradients(n *deep.Neural, p *parameters, batchSize int) {
+	scale := float64(batchSize)
+	for i, l := range n.Layers {
+		reg := r.layer(i)
+		if reg.L1 == 0 && reg.L2 == 0 {
+			continue
+		}
+		gradients := p.gradients[i]
+		var idx int
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				if !s.IsBias || reg.Bias {
+					gradients[idx] += scale * (reg.L1*sign(s.Weight) + reg.L2*s.Weight)
+				}
+				idx++
+			}
+		}
+	}
+}
+
+// constrain applies the max-norm constraint to the weights of n
+func (r *regularizer) constrain(n *deep.Neural) {
+	for i, l := range n.Layers {
+		reg := r.layer(i)
+		if reg.MaxNorm <= 0 {
+			continue
+		}
+		for _, neuron := range l.Neurons {
+			var sum float64
+			for _, s := range neuron.In {
+				if !s.IsBias || reg.Bias {
+					sum += s.Weight * s.Weight
+				}
+			}
+			norm := math.Sqrt(sum)
+			if norm <= reg.MaxNorm {
+				continue
+			}
+			for _, s := range neuron.In {
+				if !s.IsBias || reg.Bias {
+					s.Weight *= reg.MaxNorm / norm
+				}
+			}
+		}
+	}
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
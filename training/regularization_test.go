@@ -0,0 +1,122 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRegularizationNet() *deep.Neural {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{2, 1},
+		Activation: deep.ActivationTanh,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	n.ApplyWeights([][][]float64{
+		{{1, -2, 3}, {0, 4, -1}},
+		{{-3, 0.5, 2}},
+	})
+	return n
+}
+
+func Test_RegularizationPenalty(t *testing.T) {
+	n := newRegularizationNet()
+
+	r := &regularizer{all: Regularization{L1: 0.1, L2: 0.2}}
+	// Biases (3, -1, 2) are excluded
+	l1, l2 := 1.0+2+0+4+3+0.5, 1.0+4+0+16+9+0.25
+	assert.InDelta(t, 0.1*l1+0.1*l2, r.penalty(n), 1e-12)
+
+	r.all.Bias = true
+	assert.InDelta(t, 0.1*(l1+6)+0.1*(l2+14), r.penalty(n), 1e-12)
+
+	// Per layer overrides
+	r.set(1, Regularization{})
+	assert.InDelta(t, 0.1*11+0.1*31, r.penalty(n), 1e-12)
+
+	assert.Equal(t, 0.0, (*regularizer)(nil).penalty(n))
+}
+
+func Test_RegularizationGradients(t *testing.T) {
+	n := newRegularizationNet()
+	r := &regularizer{all: Regularization{L1: 0.3, L2: 0.7}}
+	r.set(0, Regularization{L2: 0.5, Bias: true})
+
+	p := newParameters(n.Layers)
+	r.gradients(n, p, 1)
+	batch := newParameters(n.Layers)
+	r.gradients(n, batch, 4)
+
+	const h = 1e-6
+	for i, l := range n.Layers {
+		var idx int
+		for _, neuron := range l.Neurons {
+			for _, s := range neuron.In {
+				w := s.Weight
+				s.Weight = w + h
+				plus := r.penalty(n)
+				s.Weight = w - h
+				minus := r.penalty(n)
+				s.Weight = w
+				assert.InDelta(t, (plus-minus)/(2*h), p.gradients[i][idx], 1e-6)
+				// Summed over a batch, like the data gradient
+				assert.InDelta(t, 4*p.gradients[i][idx], batch.gradients[i][idx], 1e-12)
+				idx++
+			}
+		}
+	}
+}
+
+func Test_MaxNorm(t *testing.T) {
+	n := newRegularizationNet()
+	r := &regularizer{all: Regularization{MaxNorm: 2}}
+	r.constrain(n)
+
+	w := n.Weights()
+	assert.InDeltaSlice(t, []float64{2 / math.Sqrt(5), -4 / math.Sqrt(5), 3}, w[0][0], 1e-12)
+	assert.InDeltaSlice(t, []float64{0, 2, -1}, w[0][1], 1e-12)
+	assert.InDeltaSlice(t, []float64{-3 * 2 / math.Sqrt(9.25), 0.5 * 2 / math.Sqrt(9.25), 2}, w[1][0], 1e-12)
+}
+
+func Test_RegularizedTraining(t *testing.T) {
+	data := Examples{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{1, 1}, []float64{0}},
+	}
+
+	norm := func(n *deep.Neural) float64 {
+		var sum float64
+		for _, l := range n.Weights() {
+			for _, w := range l {
+				for _, v := range w {
+					sum += v * v
+				}
+			}
+		}
+		return math.Sqrt(sum)
+	}
+
+	train := func(r Regularization) *deep.Neural {
+		n := newRegularizationNet()
+		trainer := NewBatchTrainer(NewSGD(0.1, 0, 0, false), 0, 4, 1)
+		trainer.SetRegularization(r)
+		trainer.Train(n, data, nil, 200)
+		return n
+	}
+
+	plain := train(Regularization{})
+	decayed := train(Regularization{L2: 0.5, Bias: true})
+	assert.True(t, norm(decayed) < norm(plain)/2)
+
+	constrained := train(Regularization{MaxNorm: 1})
+	for _, neuron := range constrained.Layers[0].Neurons {
+		assert.True(t, math.Hypot(neuron.In[0].Weight, neuron.In[1].Weight) <= 1+1e-12)
+	}
+}
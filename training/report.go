@@ -0,0 +1,235 @@
+package training
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	deep "github.com/patrikeh/go-deep"
+)
+
+// Report is the evaluation of a classifier over a set of examples
+type Report struct {
+	Labels    []string `json:"labels"`
+	Threshold float64  `json:"threshold,omitempty"`
+	// Confusion counts examples by actual (row) and predicted (column)
+	// class, and is nil for multilabel models
+	Confusion [][]int       `json:"confusion,omitempty"`
+	Classes   []ClassReport `json:"classes"`
+	Accuracy  float64       `json:"accuracy"`
+	Macro     Average       `json:"macro_avg"`
+	// Weighted averages classes by their support
+	Weighted Average `json:"weighted_avg"`
+	Support  int     `json:"support"`
+}
+
+// ClassReport holds the scores of a single class
+type ClassReport struct {
+	Label     string  `json:"label"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Support is the number of examples of the class
+	Support int `json:"support"`
+}
+
+// Average is an average of scores over classes
+type Average struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// ReportOptions configures an evaluation
+type ReportOptions struct {
+	// Labels names the classes, by default their indices
+	Labels []string
+	// Threshold is the decision threshold of binary and multilabel
+	// models, 0.5 by default
+	Threshold float64
+}
+
+// Evaluate reports on a classifier with default options
+func Evaluate(n *deep.Neural, examples Examples) (*Report, error) {
+	return EvaluateWith(n, examples, ReportOptions{})
+}
+
+// EvaluateWith reports on a ModeMultiClass, ModeBinary or ModeMultiLabel
+// model over examples of network inputs, on its calibrated outputs. A
+// binary model with a single output is reported as two classes, the second
+// being the positive one.
+func EvaluateWith(n *deep.Neural, examples Examples, o ReportOptions) (*Report, error) {
+	mode := n.Config.Mode
+	if mode != deep.ModeMultiClass && mode != deep.ModeBinary && mode != deep.ModeMultiLabel {
+		return nil, fmt.Errorf("cannot report on mode %d, only classifiers", mode)
+	}
+	threshold := fparam(o.Threshold, 0.5)
+	outputs := n.Config.Layout[len(n.Config.Layout)-1]
+	classes := outputs
+	single := mode == deep.ModeBinary && outputs == 1
+	if single {
+		classes = 2
+	}
+	multilabel := mode == deep.ModeMultiLabel || mode == deep.ModeBinary && !single
+
+	labels := o.Labels
+	if labels == nil {
+		labels = make([]string, classes)
+		for i := range labels {
+			labels[i] = strconv.Itoa(i)
+		}
+	}
+	if len(labels) != classes {
+		return nil, fmt.Errorf("expected %d labels, got %d", classes, len(labels))
+	}
+
+	r := &Report{Labels: labels, Support: len(examples)}
+	if mode != deep.ModeMultiClass {
+		r.Threshold = threshold
+	}
+	var tp, fp, fn, support []int
+	var correct, total int
+	if multilabel {
+		tp, fp, fn, support = make([]int, classes), make([]int, classes), make([]int, classes), make([]int, classes)
+		for _, e := range examples {
+			p := probabilities(n, e.Input)
+			for j := range p {
+				predicted, actual := p[j] >= threshold, e.Response[j] >= 0.5
+				if predicted == actual {
+					correct++
+				}
+				if actual {
+					support[j]++
+				}
+				switch {
+				case predicted && actual:
+					tp[j]++
+				case predicted:
+					fp[j]++
+				case actual:
+					fn[j]++
+				}
+			}
+			total += len(p)
+		}
+	} else {
+		r.Confusion = make([][]int, classes)
+		for i := range r.Confusion {
+			r.Confusion[i] = make([]int, classes)
+		}
+		for _, e := range examples {
+			p := probabilities(n, e.Input)
+			var predicted, actual int
+			if single {
+				if p[0] >= threshold {
+					predicted = 1
+				}
+				if e.Response[0] >= 0.5 {
+					actual = 1
+				}
+			} else {
+				predicted, actual = deep.ArgMax(p), deep.ArgMax(e.Response)
+			}
+			r.Confusion[actual][predicted]++
+		}
+		tp, fp, fn, support = r.counts()
+		for j := range tp {
+			correct += tp[j]
+		}
+		total = len(examples)
+	}
+	r.Accuracy = ratio(float64(correct), float64(total))
+
+	var supported int
+	for j := 0; j < classes; j++ {
+		truePos, falsePos, falseNeg := float64(tp[j]), float64(fp[j]), float64(fn[j])
+		c := ClassReport{
+			Label:     labels[j],
+			Precision: precision(truePos, falsePos, falseNeg),
+			Recall:    recall(truePos, falsePos, falseNeg),
+			F1:        f1(truePos, falsePos, falseNeg),
+			Support:   support[j],
+		}
+		r.Classes = append(r.Classes, c)
+		w := float64(c.Support)
+		supported += c.Support
+		r.Macro.Precision += c.Precision / float64(classes)
+		r.Macro.Recall += c.Recall / float64(classes)
+		r.Macro.F1 += c.F1 / float64(classes)
+		r.Weighted.Precision += c.Precision * w
+		r.Weighted.Recall += c.Recall * w
+		r.Weighted.F1 += c.F1 * w
+	}
+	if supported > 0 {
+		r.Weighted.Precision /= float64(supported)
+		r.Weighted.Recall /= float64(supported)
+		r.Weighted.F1 /= float64(supported)
+	}
+	return r, nil
+}
+
+// counts returns true positives, false positives, false negatives and
+// support per class from the confusion matrix
+func (r *Report) counts() (tp, fp, fn, support []int) {
+	k := len(r.Confusion)
+	tp, fp, fn, support = make([]int, k), make([]int, k), make([]int, k), make([]int, k)
+	for actual, row := range r.Confusion {
+		for predicted, count := range row {
+			support[actual] += count
+			if actual == predicted {
+				tp[actual] += count
+			} else {
+				fn[actual] += count
+				fp[predicted] += count
+			}
+		}
+	}
+	return
+}
+
+// String renders the report as text tables
+func (r *Report) String() string {
+	width := len("weighted avg")
+	for _, l := range r.Labels {
+		if len(l) > width {
+			width = len(l)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-*s %10s %10s %10s %10s\n\n", width, "", "precision", "recall", "f1", "support")
+	for _, c := range r.Classes {
+		fmt.Fprintf(&b, "%-*s %10.4f %10.4f %10.4f %10d\n", width, c.Label, c.Precision, c.Recall, c.F1, c.Support)
+	}
+	fmt.Fprintf(&b, "\n%-*s %10s %10s %10.4f %10d\n", width, "accuracy", "", "", r.Accuracy, r.Support)
+	for _, avg := range []struct {
+		name string
+		Average
+	}{{"macro avg", r.Macro}, {"weighted avg", r.Weighted}} {
+		fmt.Fprintf(&b, "%-*s %10.4f %10.4f %10.4f %10d\n", width, avg.name, avg.Precision, avg.Recall, avg.F1, r.Support)
+	}
+
+	if r.Confusion != nil {
+		b.WriteString("\nconfusion (rows actual, columns predicted)\n")
+		fmt.Fprintf(&b, "%-*s", width, "")
+		for _, l := range r.Labels {
+			fmt.Fprintf(&b, " %10s", l)
+		}
+		b.WriteString("\n")
+		for i, row := range r.Confusion {
+			fmt.Fprintf(&b, "%-*s", width, r.Labels[i])
+			for _, count := range row {
+				fmt.Fprintf(&b, " %10d", count)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
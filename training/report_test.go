@@ -0,0 +1,123 @@
+package training
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+// identity returns a network predicting the class given by the argmax of
+// its input
+func identity(inputs int, mode deep.Mode) *deep.Neural {
+	n := deep.NewNeural(&deep.Config{Inputs: inputs, Layout: []int{inputs}, Mode: mode})
+	weights := make([][][]float64, 1)
+	for i := 0; i < inputs; i++ {
+		w := make([]float64, inputs)
+		w[i] = 20
+		weights[0] = append(weights[0], w)
+	}
+	n.ApplyWeights(weights)
+	return n
+}
+
+func Test_EvaluateMultiClass(t *testing.T) {
+	one := func(i int) []float64 {
+		res := make([]float64, 3)
+		res[i] = 1
+		return res
+	}
+	// Predicted, actual
+	pairs := [][2]int{{0, 0}, {0, 0}, {1, 0}, {1, 1}, {2, 2}, {1, 2}}
+	var examples Examples
+	for _, p := range pairs {
+		examples = append(examples, Example{one(p[0]), one(p[1])})
+	}
+
+	r, err := EvaluateWith(identity(3, deep.ModeMultiClass), examples, ReportOptions{Labels: []string{"a", "b", "c"}})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{2, 1, 0}, {0, 1, 0}, {0, 1, 1}}, r.Confusion)
+	assert.InDelta(t, 4.0/6, r.Accuracy, 1e-12)
+	assert.Equal(t, ClassReport{Label: "a", Precision: 1, Recall: 2.0 / 3, F1: 0.8, Support: 3}, r.Classes[0])
+	assert.InDelta(t, 1.0/3, r.Classes[1].Precision, 1e-12)
+	assert.InDelta(t, (1+1.0/3+1)/3, r.Macro.Precision, 1e-12)
+	assert.InDelta(t, (3*1+1*1.0/3+2*1)/6.0, r.Weighted.Precision, 1e-12)
+	assert.Equal(t, 0.0, r.Threshold)
+
+	text := r.String()
+	assert.Contains(t, text, "weighted avg")
+	assert.Contains(t, text, "confusion")
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteJSON(&buf))
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *r, decoded)
+	assert.Contains(t, buf.String(), `"macro_avg"`)
+
+	_, err = EvaluateWith(identity(3, deep.ModeMultiClass), examples, ReportOptions{Labels: []string{"a"}})
+	assert.Error(t, err)
+	_, err = Evaluate(identity(3, deep.ModeRegression), examples)
+	assert.Error(t, err)
+}
+
+func Test_EvaluateBinary(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{1}, Mode: deep.ModeBinary})
+	n.ApplyWeights([][][]float64{{{1}}})
+	// Predictions are sigmoid(x)
+	examples := Examples{
+		{[]float64{2}, []float64{1}},
+		{[]float64{0.5}, []float64{1}},
+		{[]float64{0.5}, []float64{0}},
+		{[]float64{-2}, []float64{0}},
+	}
+
+	r, err := Evaluate(n, examples)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, r.Labels)
+	assert.Equal(t, [][]int{{1, 1}, {0, 2}}, r.Confusion)
+	assert.Equal(t, 0.5, r.Threshold)
+
+	// sigmoid(0.5) is about 0.62
+	r, err = EvaluateWith(n, examples, ReportOptions{Threshold: 0.7})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{2, 0}, {1, 1}}, r.Confusion)
+	assert.Equal(t, 0.75, r.Accuracy)
+}
+
+func Test_EvaluateNetworkInputs(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{Inputs: 1, Layout: []int{1}, Mode: deep.ModeBinary})
+	n.ApplyWeights([][][]float64{{{1}}})
+	examples := Examples{
+		{[]float64{2}, []float64{1}},
+		{[]float64{0.5}, []float64{1}},
+		{[]float64{0.5}, []float64{0}},
+		{[]float64{-2}, []float64{0}},
+	}
+	// Preprocessing that would flip every prediction is left to Predict
+	scaler := deep.NewStandardScaler()
+	scaler.Fit([][]float64{{10}, {20}})
+	n.Preprocessing = deep.Pipeline{scaler}
+
+	r, err := Evaluate(n, examples)
+	assert.NoError(t, err)
+	_, metrics := Validate(n, examples, []Metric{NewAccuracy(0)})
+	assert.Equal(t, metrics["accuracy"], r.Accuracy)
+	assert.Equal(t, 0.75, r.Accuracy)
+}
+
+func Test_EvaluateMultiLabel(t *testing.T) {
+	examples := Examples{
+		{[]float64{1, 1}, []float64{1, 0}},
+		{[]float64{-1, 1}, []float64{0, 1}},
+	}
+	r, err := Evaluate(identity(2, deep.ModeMultiLabel), examples)
+	assert.NoError(t, err)
+	assert.Nil(t, r.Confusion)
+	assert.Equal(t, 0.75, r.Accuracy)
+	assert.Equal(t, 1, r.Classes[0].Support)
+	assert.Equal(t, 0.5, r.Classes[1].Precision)
+	assert.NotContains(t, r.String(), "confusion")
+}
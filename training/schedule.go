@@ -0,0 +1,329 @@
+package training
+
+import "math"
+
+// Schedule determines the learning rate at a given epoch, as a factor of
+// the base learning rate of a solver
+type Schedule interface {
+	Factor(epoch int) float64
+}
+
+// LossObserver is implemented by schedules and solvers adapting to the
+// validation loss, which trainers report at the end of every epoch
+type LossObserver interface {
+	Observe(epoch int, loss float64)
+}
+
+// AdjustableSolver is a Solver with an adjustable learning rate
+type AdjustableSolver interface {
+	Solver
+	LearningRate() float64
+	SetLearningRate(lr float64)
+}
+
+// Scheduled is a solver whose learning rate follows a schedule
+type Scheduled struct {
+	solver   Solver
+	vector   VectorSolver
+	schedule Schedule
+	base     float64
+	epoch    int
+	// scale multiplies the updates of solvers without a learning rate
+	scale float64
+	prev  []float64
+}
+
+// resettable is implemented by schedules holding state across epochs,
+// which is reset when training starts
+type resettable interface {
+	Reset()
+}
+
+// WithSchedule composes solver with a learning rate schedule. The learning
+// rate of an AdjustableSolver follows the schedule, while the updates of
+// any other Solver are scaled by it.
+func WithSchedule(solver Solver, schedule Schedule) *Scheduled {
+	s := &Scheduled{
+		solver:   solver,
+		vector:   Vectorize(solver),
+		schedule: schedule,
+		base:     1,
+		scale:    1,
+	}
+	if a, ok := solver.(AdjustableSolver); ok {
+		s.base = a.LearningRate()
+	}
+	return s
+}
+
+// Init initializes the underlying solver and resets the schedule
+func (s *Scheduled) Init(size int) {
+	s.epoch = 0
+	if r, ok := s.schedule.(resettable); ok {
+		r.Reset()
+	}
+	s.setFactor(1)
+	s.vector.Init(size)
+}
+
+// Update returns the update for a given weight
+func (s *Scheduled) Update(value, gradient float64, iteration, idx int) float64 {
+	s.advance(iteration)
+	return s.scale * s.solver.Update(value, gradient, iteration, idx)
+}
+
+// Step sets the learning rate for the current iteration
+func (s *Scheduled) Step(iteration int) {
+	s.advance(iteration)
+	s.vector.Step(iteration)
+}
+
+// UpdateVector updates a vector of weights in place
+func (s *Scheduled) UpdateVector(params, gradients []float64, offset int) {
+	if s.scale == 1 {
+		s.vector.UpdateVector(params, gradients, offset)
+		return
+	}
+	s.prev = append(s.prev[:0], params...)
+	s.vector.UpdateVector(params, gradients, offset)
+	for i, p := range s.prev {
+		params[i] = p + s.scale*(params[i]-p)
+	}
+}
+
+// LearningRate returns the current learning rate, or for solvers without
+// a learning rate the current scale of their updates
+func (s *Scheduled) LearningRate() float64 {
+	if a, ok := s.solver.(AdjustableSolver); ok {
+		return a.LearningRate()
+	}
+	return s.scale
+}
+
+// SetLearningRate sets the base learning rate
+func (s *Scheduled) SetLearningRate(lr float64) {
+	s.base = lr
+	s.setFactor(s.schedule.Factor(s.epoch))
+}
+
+// Observe passes the validation loss on to the schedule
+func (s *Scheduled) Observe(epoch int, loss float64) {
+	if o, ok := s.schedule.(LossObserver); ok {
+		o.Observe(epoch, loss)
+	}
+	if o, ok := s.solver.(LossObserver); ok {
+		o.Observe(epoch, loss)
+	}
+}
+
+func (s *Scheduled) advance(epoch int) {
+	if epoch != s.epoch {
+		s.epoch = epoch
+		s.setFactor(s.schedule.Factor(epoch))
+	}
+}
+
+func (s *Scheduled) setFactor(factor float64) {
+	if a, ok := s.solver.(AdjustableSolver); ok {
+		a.SetLearningRate(s.base * factor)
+		return
+	}
+	s.scale = s.base * factor
+}
+
+// learningRate returns a function reporting the current learning
+// rate of s if it is scheduled
+func learningRate(s VectorSolver) func() float64 {
+	if scheduled, ok := s.(*Scheduled); ok {
+		return scheduled.LearningRate
+	}
+	return nil
+}
+
+// StepDecay multiplies the learning rate by gamma every n epochs
+type StepDecay struct {
+	every int
+	gamma float64
+}
+
+// NewStepDecay returns a new step decay schedule
+func NewStepDecay(every int, gamma float64) *StepDecay {
+	return &StepDecay{
+		every: iparam(every, 10),
+		gamma: fparam(gamma, 0.5),
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *StepDecay) Factor(epoch int) float64 {
+	return math.Pow(s.gamma, float64((epoch-1)/s.every))
+}
+
+// ExponentialDecay multiplies the learning rate by gamma every epoch
+type ExponentialDecay struct {
+	gamma float64
+}
+
+// NewExponentialDecay returns a new exponential decay schedule
+func NewExponentialDecay(gamma float64) *ExponentialDecay {
+	return &ExponentialDecay{
+		gamma: fparam(gamma, 0.99),
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *ExponentialDecay) Factor(epoch int) float64 {
+	return math.Pow(s.gamma, float64(epoch-1))
+}
+
+// CosineAnnealing anneals the learning rate along a cosine curve,
+// restarting every period epochs, where each period is mult times
+// longer than the previous
+type CosineAnnealing struct {
+	period int
+	mult   float64
+	min    float64
+}
+
+// NewCosineAnnealing returns a new cosine annealing schedule with warm
+// restarts, where min is the smallest factor reached within a period
+func NewCosineAnnealing(period int, mult, min float64) *CosineAnnealing {
+	return &CosineAnnealing{
+		period: iparam(period, 10),
+		mult:   fparam(mult, 1),
+		min:    min,
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *CosineAnnealing) Factor(epoch int) float64 {
+	t, period := float64(epoch-1), float64(s.period)
+	for t >= period {
+		t -= period
+		period *= s.mult
+	}
+	return s.min + (1-s.min)*(1+math.Cos(math.Pi*t/period))/2
+}
+
+// LinearWarmup linearly increases the learning rate over the first
+// epochs, after which an optional schedule takes over
+type LinearWarmup struct {
+	epochs int
+	then   Schedule
+}
+
+// NewLinearWarmup returns a new warmup schedule, then may be nil
+func NewLinearWarmup(epochs int, then Schedule) *LinearWarmup {
+	return &LinearWarmup{
+		epochs: iparam(epochs, 5),
+		then:   then,
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *LinearWarmup) Factor(epoch int) float64 {
+	if epoch <= s.epochs {
+		return float64(epoch) / float64(s.epochs)
+	}
+	if s.then == nil {
+		return 1
+	}
+	return s.then.Factor(epoch - s.epochs)
+}
+
+// Reset resets the following schedule
+func (s *LinearWarmup) Reset() {
+	if r, ok := s.then.(resettable); ok {
+		r.Reset()
+	}
+}
+
+// Observe passes the validation loss on to the following schedule
+func (s *LinearWarmup) Observe(epoch int, loss float64) {
+	if o, ok := s.then.(LossObserver); ok && epoch > s.epochs {
+		o.Observe(epoch-s.epochs, loss)
+	}
+}
+
+// OneCycle raises the learning rate from start to the base rate over a
+// fraction of the epochs, then anneals it to end
+type OneCycle struct {
+	epochs     int
+	warmup     float64
+	start, end float64
+}
+
+// NewOneCycle returns a new one-cycle schedule over the given number of epochs
+func NewOneCycle(epochs int, warmup, start, end float64) *OneCycle {
+	return &OneCycle{
+		epochs: iparam(epochs, 100),
+		warmup: fparam(warmup, 0.3),
+		start:  fparam(start, 0.04),
+		end:    fparam(end, 1e-4),
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *OneCycle) Factor(epoch int) float64 {
+	t := float64(epoch - 1)
+	up := math.Max(1, math.Round(s.warmup*float64(s.epochs)))
+	if t < up {
+		return cosineInterpolate(s.start, 1, t/up)
+	}
+	down := math.Max(1, float64(s.epochs)-up-1)
+	return cosineInterpolate(1, s.end, math.Min(1, (t-up)/down))
+}
+
+func cosineInterpolate(from, to, p float64) float64 {
+	return to + (from-to)*(1+math.Cos(math.Pi*p))/2
+}
+
+// ReduceOnPlateau reduces the learning rate when the validation loss
+// stops improving
+type ReduceOnPlateau struct {
+	factor   float64
+	patience int
+	minDelta float64
+	min      float64
+
+	best    float64
+	wait    int
+	current float64
+}
+
+// NewReduceOnPlateau returns a schedule multiplying the learning rate by
+// factor whenever the validation loss has not improved by minDelta for
+// patience epochs, down to a smallest factor of min
+func NewReduceOnPlateau(factor float64, patience int, minDelta, min float64) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		factor:   fparam(factor, 0.1),
+		patience: iparam(patience, 10),
+		minDelta: fparam(minDelta, 1e-4),
+		min:      min,
+		best:     math.Inf(1),
+		current:  1,
+	}
+}
+
+// Factor returns the learning rate factor at epoch
+func (s *ReduceOnPlateau) Factor(epoch int) float64 {
+	return s.current
+}
+
+// Reset forgets the losses observed so far and restores the full
+// learning rate
+func (s *ReduceOnPlateau) Reset() {
+	s.best, s.wait, s.current = math.Inf(1), 0, 1
+}
+
+// Observe records the validation loss at the end of an epoch
+func (s *ReduceOnPlateau) Observe(epoch int, loss float64) {
+	if loss < s.best-s.minDelta {
+		s.best, s.wait = loss, 0
+		return
+	}
+	s.wait++
+	if s.wait >= s.patience {
+		s.current, s.wait = math.Max(s.current*s.factor, s.min), 0
+	}
+}
@@ -0,0 +1,141 @@
+package training
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func factors(s Schedule, epochs int) []float64 {
+	res := make([]float64, epochs)
+	for i := range res {
+		res[i] = s.Factor(i + 1)
+	}
+	return res
+}
+
+func Test_Schedules(t *testing.T) {
+	assert.Equal(t, []float64{1, 1, 0.5, 0.5, 0.25}, factors(NewStepDecay(2, 0.5), 5))
+	assert.InDeltaSlice(t, []float64{1, 0.9, 0.81}, factors(NewExponentialDecay(0.9), 3), 1e-12)
+
+	cosine := factors(NewCosineAnnealing(4, 2, 0.1), 13)
+	assert.InDelta(t, 1, cosine[0], 1e-12)
+	assert.InDelta(t, 0.55, cosine[2], 1e-12)
+	// Restart after 4 epochs, then a period of 8
+	assert.InDelta(t, 1, cosine[4], 1e-12)
+	assert.InDelta(t, 0.55, cosine[8], 1e-12)
+	assert.InDelta(t, 1, cosine[12], 1e-12)
+
+	warmup := factors(NewLinearWarmup(4, NewStepDecay(1, 0.5)), 6)
+	assert.Equal(t, []float64{0.25, 0.5, 0.75, 1, 1, 0.5}, warmup)
+
+	cycle := factors(NewOneCycle(10, 0.3, 0.1, 0.01), 12)
+	assert.InDelta(t, 0.1, cycle[0], 1e-12)
+	assert.InDelta(t, 1, cycle[3], 1e-12)
+	assert.InDelta(t, 0.01, cycle[9], 1e-12)
+	assert.InDelta(t, 0.01, cycle[11], 1e-12)
+	for i := 1; i < 4; i++ {
+		assert.True(t, cycle[i] > cycle[i-1])
+	}
+	for i := 4; i < 10; i++ {
+		assert.True(t, cycle[i] < cycle[i-1])
+	}
+}
+
+func Test_ReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(0.5, 2, 0.01, 0.2)
+	losses := []float64{1, 0.5, 0.495, 0.6, 0.4, 0.4, 0.4, 0.4, 0.4, 0.4, 0.4}
+	expected := []float64{1, 1, 1, 0.5, 0.5, 0.5, 0.25, 0.25, 0.2, 0.2, 0.2}
+	for i, l := range losses {
+		s.Observe(i+1, l)
+		assert.Equal(t, expected[i], s.Factor(i+2), "epoch %d", i+1)
+	}
+}
+
+func Test_Scheduled(t *testing.T) {
+	sgd := NewSGD(0.1, 0, 0, false)
+	s := WithSchedule(sgd, NewStepDecay(1, 0.5))
+	s.Init(1)
+
+	assert.Equal(t, -0.1, s.Update(0, 1, 1, 0))
+	assert.Equal(t, -0.05, s.Update(0, 1, 2, 0))
+	assert.Equal(t, 0.05, s.LearningRate())
+
+	params := []float64{0}
+	s.Step(3)
+	s.UpdateVector(params, []float64{1}, 0)
+	assert.Equal(t, []float64{-0.025}, params)
+
+	s.SetLearningRate(1)
+	assert.Equal(t, 0.25, s.LearningRate())
+
+	// Restarting training resets the learning rate
+	s.Init(1)
+	assert.Equal(t, 1.0, s.LearningRate())
+}
+
+// descent is a plain solver without a learning rate
+type descent struct{}
+
+func (descent) Init(size int) {}
+
+func (descent) Update(value, gradient float64, iteration, idx int) float64 {
+	return -gradient
+}
+
+func Test_ScheduledSolver(t *testing.T) {
+	s := WithSchedule(descent{}, NewStepDecay(1, 0.5))
+	s.Init(1)
+	assert.Equal(t, -1.0, s.Update(0, 1, 1, 0))
+	assert.Equal(t, -0.5, s.Update(0, 1, 2, 0))
+	assert.Equal(t, 0.5, s.LearningRate())
+
+	params := []float64{1, 2}
+	s.Step(3)
+	s.UpdateVector(params, []float64{1, -1}, 0)
+	assert.Equal(t, []float64{0.75, 2.25}, params)
+}
+
+func Test_ScheduledTraining(t *testing.T) {
+	n := deep.NewNeural(&deep.Config{
+		Inputs:     2,
+		Layout:     []int{3, 1},
+		Activation: deep.ActivationSigmoid,
+		Mode:       deep.ModeBinary,
+		Weight:     deep.NewNormal(1, 0),
+		Bias:       true,
+	})
+	data := Examples{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{1, 1}, []float64{0}},
+	}
+
+	plateau := NewReduceOnPlateau(0.5, 1, 1, 0)
+	solver := WithSchedule(NewAdam(0.01, 0, 0, 0), plateau)
+	trainer := NewBatchTrainer(solver, 0, 2, 1)
+	trainer.Train(n, data, data, 5)
+
+	// Loss never improves by 1, so the rate halves after every epoch but
+	// the first, and the last epoch trains at 1/8 of the base rate
+	assert.InDelta(t, 0.01/8, solver.LearningRate(), 1e-12)
+	assert.NotNil(t, trainer.printer.rate)
+
+	// Training again starts over from the base rate, and the printer
+	// reports the scheduled rate of every epoch
+	var buf bytes.Buffer
+	trainer = NewBatchTrainer(solver, 1, 2, 1)
+	trainer.SetPrinter(NewWriterPrinter(&buf, FormatCSV))
+	trainer.Train(n, data, data, 3)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	for i, rate := range []float64{0.01, 0.01, 0.005} {
+		assert.Equal(t, rate, trainer.History().Records[i].LearningRate)
+		assert.Equal(t, formatFloat(rate), rows[i+1][len(rows[i+1])-1])
+	}
+}
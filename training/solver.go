@@ -8,6 +8,42 @@ type Solver interface {
 	Update(value, gradient float64, iteration, idx int) float64
 }
 
+// VectorSolver implements an update rule operating on whole parameter
+// vectors. Step is called once per update step, before UpdateVector is
+// called for each layer, so that terms shared by all weights are only
+// computed once per step.
+type VectorSolver interface {
+	Init(size int)
+	Step(iteration int)
+	// UpdateVector updates params in place, where offset is the index
+	// of params[0] among all weights in the network
+	UpdateVector(params, gradients []float64, offset int)
+}
+
+// Vectorize returns s as a VectorSolver, adapting solvers that only
+// implement per weight updates
+func Vectorize(s Solver) VectorSolver {
+	if v, ok := s.(VectorSolver); ok {
+		return v
+	}
+	return &vectorized{Solver: s}
+}
+
+type vectorized struct {
+	Solver
+	iteration int
+}
+
+func (v *vectorized) Step(iteration int) {
+	v.iteration = iteration
+}
+
+func (v *vectorized) UpdateVector(params, gradients []float64, offset int) {
+	for i := range params {
+		params[i] += v.Update(params[i], gradients[i], v.iteration, offset+i)
+	}
+}
+
 // SGD is stochastic gradient descent with nesterov/momentum
 type SGD struct {
 	lr       float64
@@ -15,6 +51,8 @@ type SGD struct {
 	momentum float64
 	nesterov bool
 	moments  []float64
+
+	lrt float64
 }
 
 // NewSGD returns a new SGD solver
@@ -32,6 +70,12 @@ func (o *SGD) Init(size int) {
 	o.moments = make([]float64, size)
 }
 
+// LearningRate returns the base learning rate
+func (o *SGD) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *SGD) SetLearningRate(lr float64) { o.lr = lr }
+
 // Update returns the update for a given weight
 func (o *SGD) Update(value, gradient float64, iteration, idx int) float64 {
 	lr := o.lr / (1 + o.decay*float64(iteration))
@@ -45,6 +89,23 @@ func (o *SGD) Update(value, gradient float64, iteration, idx int) float64 {
 	return o.moments[idx]
 }
 
+// Step computes the learning rate for the current iteration
+func (o *SGD) Step(iteration int) {
+	o.lrt = o.lr / (1 + o.decay*float64(iteration))
+}
+
+// UpdateVector updates a vector of weights in place
+func (o *SGD) UpdateVector(params, gradients []float64, offset int) {
+	moments := o.moments[offset : offset+len(params)]
+	for i, g := range gradients {
+		moments[i] = o.momentum*moments[i] - o.lrt*g
+		if o.nesterov {
+			moments[i] = o.momentum*moments[i] - o.lrt*g
+		}
+		params[i] += moments[i]
+	}
+}
+
 // Adam is an Adam solver
 type Adam struct {
 	lr      float64
@@ -53,6 +114,7 @@ type Adam struct {
 	epsilon float64
 
 	v, m []float64
+	lrt  float64
 }
 
 // NewAdam returns a new Adam solver
@@ -70,6 +132,12 @@ func (o *Adam) Init(size int) {
 	o.v, o.m = make([]float64, size), make([]float64, size)
 }
 
+// LearningRate returns the base learning rate
+func (o *Adam) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *Adam) SetLearningRate(lr float64) { o.lr = lr }
+
 // Update returns the update for a given weight
 func (o *Adam) Update(value, gradient float64, t, idx int) float64 {
 	lrt := o.lr * (math.Sqrt(1.0 - math.Pow(o.beta2, float64(t)))) /
@@ -80,6 +148,319 @@ func (o *Adam) Update(value, gradient float64, t, idx int) float64 {
 	return -lrt * (o.m[idx] / (math.Sqrt(o.v[idx]) + o.epsilon))
 }
 
+// Step computes the bias corrected learning rate for the current iteration
+func (o *Adam) Step(t int) {
+	o.lrt = o.lr * (math.Sqrt(1.0 - math.Pow(o.beta2, float64(t)))) /
+		(1.0 - math.Pow(o.beta, float64(t)))
+}
+
+// UpdateVector updates a vector of weights in place
+func (o *Adam) UpdateVector(params, gradients []float64, offset int) {
+	m, v := o.m[offset:offset+len(params)], o.v[offset:offset+len(params)]
+	for i, g := range gradients {
+		m[i] = o.beta*m[i] + (1.0-o.beta)*g
+		v[i] = o.beta2*v[i] + (1.0-o.beta2)*g*g
+		params[i] += -o.lrt * (m[i] / (math.Sqrt(v[i]) + o.epsilon))
+	}
+}
+
+// RMSProp is an RMSProp solver
+type RMSProp struct {
+	lr      float64
+	rho     float64
+	epsilon float64
+
+	v []float64
+}
+
+// NewRMSProp returns a new RMSProp solver
+func NewRMSProp(lr, rho, epsilon float64) *RMSProp {
+	return &RMSProp{
+		lr:      fparam(lr, 0.001),
+		rho:     fparam(rho, 0.9),
+		epsilon: fparam(epsilon, 1e-8),
+	}
+}
+
+// Init initializes vectors using number of weights in network
+func (o *RMSProp) Init(size int) {
+	o.v = make([]float64, size)
+}
+
+// LearningRate returns the base learning rate
+func (o *RMSProp) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *RMSProp) SetLearningRate(lr float64) { o.lr = lr }
+
+// Update returns the update for a given weight
+func (o *RMSProp) Update(value, gradient float64, t, idx int) float64 {
+	o.v[idx] = o.rho*o.v[idx] + (1.0-o.rho)*gradient*gradient
+
+	return -o.lr * gradient / (math.Sqrt(o.v[idx]) + o.epsilon)
+}
+
+// Step is a no-op
+func (o *RMSProp) Step(t int) {}
+
+// UpdateVector updates a vector of weights in place
+func (o *RMSProp) UpdateVector(params, gradients []float64, offset int) {
+	v := o.v[offset : offset+len(params)]
+	for i, g := range gradients {
+		v[i] = o.rho*v[i] + (1.0-o.rho)*g*g
+		params[i] -= o.lr * g / (math.Sqrt(v[i]) + o.epsilon)
+	}
+}
+
+// Adagrad is an Adagrad solver
+type Adagrad struct {
+	lr      float64
+	epsilon float64
+
+	g []float64
+}
+
+// NewAdagrad returns a new Adagrad solver
+func NewAdagrad(lr, epsilon float64) *Adagrad {
+	return &Adagrad{
+		lr:      fparam(lr, 0.01),
+		epsilon: fparam(epsilon, 1e-8),
+	}
+}
+
+// Init initializes vectors using number of weights in network
+func (o *Adagrad) Init(size int) {
+	o.g = make([]float64, size)
+}
+
+// LearningRate returns the base learning rate
+func (o *Adagrad) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *Adagrad) SetLearningRate(lr float64) { o.lr = lr }
+
+// Update returns the update for a given weight
+func (o *Adagrad) Update(value, gradient float64, t, idx int) float64 {
+	o.g[idx] += gradient * gradient
+
+	return -o.lr * gradient / (math.Sqrt(o.g[idx]) + o.epsilon)
+}
+
+// Step is a no-op
+func (o *Adagrad) Step(t int) {}
+
+// UpdateVector updates a vector of weights in place
+func (o *Adagrad) UpdateVector(params, gradients []float64, offset int) {
+	acc := o.g[offset : offset+len(params)]
+	for i, g := range gradients {
+		acc[i] += g * g
+		params[i] -= o.lr * g / (math.Sqrt(acc[i]) + o.epsilon)
+	}
+}
+
+// Adadelta is an Adadelta solver
+type Adadelta struct {
+	lr      float64
+	rho     float64
+	epsilon float64
+
+	g, d []float64
+}
+
+// NewAdadelta returns a new Adadelta solver, where lr scales
+// the otherwise parameter-free updates
+func NewAdadelta(lr, rho, epsilon float64) *Adadelta {
+	return &Adadelta{
+		lr:      fparam(lr, 1.0),
+		rho:     fparam(rho, 0.95),
+		epsilon: fparam(epsilon, 1e-6),
+	}
+}
+
+// Init initializes vectors using number of weights in network
+func (o *Adadelta) Init(size int) {
+	o.g, o.d = make([]float64, size), make([]float64, size)
+}
+
+// LearningRate returns the base learning rate
+func (o *Adadelta) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *Adadelta) SetLearningRate(lr float64) { o.lr = lr }
+
+// Update returns the update for a given weight
+func (o *Adadelta) Update(value, gradient float64, t, idx int) float64 {
+	o.g[idx] = o.rho*o.g[idx] + (1.0-o.rho)*gradient*gradient
+	update := -math.Sqrt(o.d[idx]+o.epsilon) / math.Sqrt(o.g[idx]+o.epsilon) * gradient
+	o.d[idx] = o.rho*o.d[idx] + (1.0-o.rho)*update*update
+
+	return o.lr * update
+}
+
+// Step is a no-op
+func (o *Adadelta) Step(t int) {}
+
+// UpdateVector updates a vector of weights in place
+func (o *Adadelta) UpdateVector(params, gradients []float64, offset int) {
+	acc, d := o.g[offset:offset+len(params)], o.d[offset:offset+len(params)]
+	for i, g := range gradients {
+		acc[i] = o.rho*acc[i] + (1.0-o.rho)*g*g
+		update := -math.Sqrt(d[i]+o.epsilon) / math.Sqrt(acc[i]+o.epsilon) * g
+		d[i] = o.rho*d[i] + (1.0-o.rho)*update*update
+		params[i] += o.lr * update
+	}
+}
+
+// Nadam is an Adam solver with Nesterov momentum
+type Nadam struct {
+	lr      float64
+	beta    float64
+	beta2   float64
+	epsilon float64
+
+	v, m    []float64
+	bc, bc2 float64
+}
+
+// NewNadam returns a new Nadam solver
+func NewNadam(lr, beta, beta2, epsilon float64) *Nadam {
+	return &Nadam{
+		lr:      fparam(lr, 0.002),
+		beta:    fparam(beta, 0.9),
+		beta2:   fparam(beta2, 0.999),
+		epsilon: fparam(epsilon, 1e-8),
+	}
+}
+
+// Init initializes vectors using number of weights in network
+func (o *Nadam) Init(size int) {
+	o.v, o.m = make([]float64, size), make([]float64, size)
+}
+
+// LearningRate returns the base learning rate
+func (o *Nadam) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *Nadam) SetLearningRate(lr float64) { o.lr = lr }
+
+// Update returns the update for a given weight
+func (o *Nadam) Update(value, gradient float64, t, idx int) float64 {
+	bc := 1.0 - math.Pow(o.beta, float64(t))
+	bc2 := 1.0 - math.Pow(o.beta2, float64(t))
+	o.m[idx] = o.beta*o.m[idx] + (1.0-o.beta)*gradient
+	o.v[idx] = o.beta2*o.v[idx] + (1.0-o.beta2)*gradient*gradient
+
+	m := o.beta*o.m[idx]/bc + (1.0-o.beta)*gradient/bc
+	return -o.lr * m / (math.Sqrt(o.v[idx]/bc2) + o.epsilon)
+}
+
+// Step computes the bias corrections for the current iteration
+func (o *Nadam) Step(t int) {
+	o.bc = 1.0 - math.Pow(o.beta, float64(t))
+	o.bc2 = 1.0 - math.Pow(o.beta2, float64(t))
+}
+
+// UpdateVector updates a vector of weights in place
+func (o *Nadam) UpdateVector(params, gradients []float64, offset int) {
+	m, v := o.m[offset:offset+len(params)], o.v[offset:offset+len(params)]
+	for i, g := range gradients {
+		m[i] = o.beta*m[i] + (1.0-o.beta)*g
+		v[i] = o.beta2*v[i] + (1.0-o.beta2)*g*g
+		mt := o.beta*m[i]/o.bc + (1.0-o.beta)*g/o.bc
+		params[i] -= o.lr * mt / (math.Sqrt(v[i]/o.bc2) + o.epsilon)
+	}
+}
+
+// AdamW is an Adam solver with decoupled weight decay
+type AdamW struct {
+	*Adam
+	decay float64
+}
+
+// NewAdamW returns a new AdamW solver. Weights decay by lr*decay
+// each update, independently of the gradient moments.
+func NewAdamW(lr, beta, beta2, epsilon, decay float64) *AdamW {
+	return &AdamW{
+		Adam:  NewAdam(lr, beta, beta2, epsilon),
+		decay: fparam(decay, 0.01),
+	}
+}
+
+// Update returns the update for a given weight
+func (o *AdamW) Update(value, gradient float64, t, idx int) float64 {
+	return o.Adam.Update(value, gradient, t, idx) - o.lr*o.decay*value
+}
+
+// UpdateVector updates a vector of weights in place
+func (o *AdamW) UpdateVector(params, gradients []float64, offset int) {
+	decay := 1.0 - o.lr*o.decay
+	for i := range params {
+		params[i] *= decay
+	}
+	o.Adam.UpdateVector(params, gradients, offset)
+}
+
+// AMSGrad is an Adam solver using the maximum of past second moments
+type AMSGrad struct {
+	lr      float64
+	beta    float64
+	beta2   float64
+	epsilon float64
+
+	v, vMax, m []float64
+	lrt        float64
+}
+
+// NewAMSGrad returns a new AMSGrad solver
+func NewAMSGrad(lr, beta, beta2, epsilon float64) *AMSGrad {
+	return &AMSGrad{
+		lr:      fparam(lr, 0.001),
+		beta:    fparam(beta, 0.9),
+		beta2:   fparam(beta2, 0.999),
+		epsilon: fparam(epsilon, 1e-8),
+	}
+}
+
+// Init initializes vectors using number of weights in network
+func (o *AMSGrad) Init(size int) {
+	o.v, o.vMax, o.m = make([]float64, size), make([]float64, size), make([]float64, size)
+}
+
+// LearningRate returns the base learning rate
+func (o *AMSGrad) LearningRate() float64 { return o.lr }
+
+// SetLearningRate sets the base learning rate
+func (o *AMSGrad) SetLearningRate(lr float64) { o.lr = lr }
+
+// Update returns the update for a given weight
+func (o *AMSGrad) Update(value, gradient float64, t, idx int) float64 {
+	lrt := o.lr * (math.Sqrt(1.0 - math.Pow(o.beta2, float64(t)))) /
+		(1.0 - math.Pow(o.beta, float64(t)))
+	o.m[idx] = o.beta*o.m[idx] + (1.0-o.beta)*gradient
+	o.v[idx] = o.beta2*o.v[idx] + (1.0-o.beta2)*gradient*gradient
+	o.vMax[idx] = math.Max(o.vMax[idx], o.v[idx])
+
+	return -lrt * (o.m[idx] / (math.Sqrt(o.vMax[idx]) + o.epsilon))
+}
+
+// Step computes the bias corrected learning rate for the current iteration
+func (o *AMSGrad) Step(t int) {
+	o.lrt = o.lr * (math.Sqrt(1.0 - math.Pow(o.beta2, float64(t)))) /
+		(1.0 - math.Pow(o.beta, float64(t)))
+}
+
+// UpdateVector updates a vector of weights in place
+func (o *AMSGrad) UpdateVector(params, gradients []float64, offset int) {
+	m, v := o.m[offset:offset+len(params)], o.v[offset:offset+len(params)]
+	vMax := o.vMax[offset : offset+len(params)]
+	for i, g := range gradients {
+		m[i] = o.beta*m[i] + (1.0-o.beta)*g
+		v[i] = o.beta2*v[i] + (1.0-o.beta2)*g*g
+		vMax[i] = math.Max(vMax[i], v[i])
+		params[i] += -o.lrt * (m[i] / (math.Sqrt(vMax[i]) + o.epsilon))
+	}
+}
+
 func fparam(val, fallback float64) float64 {
 	if val == 0.0 {
 		return fallback
@@ -0,0 +1,86 @@
+package training
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type solverPair struct {
+	name   string
+	scalar Solver
+	vector VectorSolver
+}
+
+func Test_UpdateVector(t *testing.T) {
+	rand.Seed(0)
+
+	pairs := []solverPair{
+		{"sgd", NewSGD(0.1, 0.9, 1e-3, true), NewSGD(0.1, 0.9, 1e-3, true)},
+		{"adam", NewAdam(0.01, 0, 0, 0), NewAdam(0.01, 0, 0, 0)},
+		{"rmsprop", NewRMSProp(0.01, 0, 0), NewRMSProp(0.01, 0, 0)},
+		{"adagrad", NewAdagrad(0.1, 0), NewAdagrad(0.1, 0)},
+		{"adadelta", NewAdadelta(1, 0, 0), NewAdadelta(1, 0, 0)},
+		{"nadam", NewNadam(0.01, 0, 0, 0), NewNadam(0.01, 0, 0, 0)},
+		{"adamw", NewAdamW(0.01, 0, 0, 0, 0.1), NewAdamW(0.01, 0, 0, 0, 0.1)},
+		{"amsgrad", NewAMSGrad(0.01, 0, 0, 0), NewAMSGrad(0.01, 0, 0, 0)},
+	}
+
+	const size = 8
+	for _, p := range pairs {
+		p.scalar.Init(size)
+		p.vector.Init(size)
+
+		scalar, vector := make([]float64, size), make([]float64, size)
+		for i := range scalar {
+			scalar[i] = rand.NormFloat64()
+			vector[i] = scalar[i]
+		}
+
+		for it := 1; it <= 5; it++ {
+			gradients := make([]float64, size)
+			for i := range gradients {
+				gradients[i] = rand.NormFloat64()
+			}
+			for i := range scalar {
+				scalar[i] += p.scalar.Update(scalar[i], gradients[i], it, i)
+			}
+			p.vector.Step(it)
+			// Split into two layers
+			p.vector.UpdateVector(vector[:3], gradients[:3], 0)
+			p.vector.UpdateVector(vector[3:], gradients[3:], 3)
+		}
+		for i := range scalar {
+			assert.InDelta(t, scalar[i], vector[i], 1e-12, p.name)
+		}
+	}
+}
+
+type constantSolver struct {
+	size       int
+	iterations []int
+}
+
+func (s *constantSolver) Init(size int) { s.size = size }
+
+func (s *constantSolver) Update(value, gradient float64, iteration, idx int) float64 {
+	s.iterations = append(s.iterations, iteration)
+	return -gradient - float64(idx)
+}
+
+func Test_Vectorize(t *testing.T) {
+	adam := NewAdam(0, 0, 0, 0)
+	assert.Equal(t, adam, Vectorize(adam))
+
+	custom := &constantSolver{}
+	v := Vectorize(custom)
+	v.Init(4)
+	assert.Equal(t, 4, custom.size)
+
+	params := []float64{1, 1}
+	v.Step(7)
+	v.UpdateVector(params, []float64{0.5, 0.5}, 2)
+	assert.Equal(t, []float64{1 - 0.5 - 2, 1 - 0.5 - 3}, params)
+	assert.Equal(t, []int{7, 7}, custom.iterations)
+}
@@ -1,7 +1,16 @@
+// Package training trains and evaluates deep.Neural networks.
+//
+// Examples given to the trainers and to every function of this package
+// hold network inputs, the inputs taken by Neural.Forward: features are
+// transformed by Examples.Transform beforehand, whether or not the
+// network has preprocessing attached for Predict. Losses and metrics are
+// computed on the outputs of the network, while EvaluateWith and
+// Reliability, reporting on predicted probabilities, apply its calibrator.
 package training
 
 import (
-	"time"
+	"context"
+	"io"
 
 	deep "github.com/patrikeh/go-deep"
 )
@@ -14,90 +23,189 @@ type Trainer interface {
 // OnlineTrainer is a basic, online network trainer
 type OnlineTrainer struct {
 	*internal
-	solver    Solver
-	printer   *StatsPrinter
-	verbosity int
+	solver      VectorSolver
+	printer     *StatsPrinter
+	verbosity   int
+	clipping    Clipping
+	regularizer regularizer
+	gradNorm    float64
+	callbacks   []Callback
+	history     *History
+	metrics     []Metric
 }
 
 // NewTrainer creates a new trainer
 func NewTrainer(solver Solver, verbosity int) *OnlineTrainer {
+	printer := NewStatsPrinter()
+	printer.verbosity = verbosity
 	return &OnlineTrainer{
-		solver:    solver,
-		printer:   NewStatsPrinter(),
+		solver:    Vectorize(solver),
+		printer:   printer,
 		verbosity: verbosity,
+		history:   &History{},
 	}
 }
 
+// SetMetrics sets the metrics computed over the validation set every
+// epoch, replacing the defaults of the network mode
+func (t *OnlineTrainer) SetMetrics(m ...Metric) {
+	t.metrics = m
+}
+
+// SetPrinter replaces the printer reporting training progress
+func (t *OnlineTrainer) SetPrinter(p *StatsPrinter) {
+	p.verbosity = t.verbosity
+	t.printer = p
+}
+
+// AddCallback registers callbacks to be notified during training
+func (t *OnlineTrainer) AddCallback(c ...Callback) {
+	t.callbacks = append(t.callbacks, c...)
+}
+
+// SetClipping sets the gradient clipping applied before every update
+func (t *OnlineTrainer) SetClipping(c Clipping) {
+	t.clipping = c
+}
+
+// SetRegularization sets the weight regularization of every layer
+func (t *OnlineTrainer) SetRegularization(r Regularization) {
+	t.regularizer.all = r
+}
+
+// SetLayerRegularization sets the weight regularization of layer i,
+// overriding SetRegularization
+func (t *OnlineTrainer) SetLayerRegularization(i int, r Regularization) {
+	t.regularizer.set(i, r)
+}
+
+// History returns the per epoch history of the last training run
+func (t *OnlineTrainer) History() *History {
+	return t.history
+}
+
+// GradientNorm returns the global L2 norm of the gradients of the last
+// update, before clipping
+func (t *OnlineTrainer) GradientNorm() float64 {
+	return t.gradNorm
+}
+
 type internal struct {
 	deltas [][]float64
+	params *parameters
+	loss   *exampleLoss
 }
 
-func newTraining(layers []*deep.Layer) *internal {
-	deltas := make([][]float64, len(layers))
-	for i, l := range layers {
+func newTraining(n *deep.Neural) *internal {
+	deltas := make([][]float64, len(n.Layers))
+	for i, l := range n.Layers {
 		deltas[i] = make([]float64, len(l.Neurons))
 	}
 	return &internal{
 		deltas: deltas,
+		params: newParameters(n.Layers),
+		loss:   newExampleLoss(n),
 	}
 }
 
 // Train trains n
 func (t *OnlineTrainer) Train(n *deep.Neural, examples, validation Examples, iterations int) {
-	t.internal = newTraining(n.Layers)
+	t.TrainContext(context.Background(), n, examples, validation, iterations)
+}
+
+// TrainContext trains n until done or ctx is done, in which case training
+// stops after the current example and ctx.Err() is returned
+func (t *OnlineTrainer) TrainContext(ctx context.Context, n *deep.Neural, examples, validation Examples, iterations int) error {
+	return t.TrainDataset(ctx, n, NewMemoryDataset(examples, true), validation, iterations)
+}
 
-	t.printer.Init(n)
+// TrainDataset trains n on iterations passes over examples. It stops on
+// the first error of examples or when ctx is done, returning the error.
+func (t *OnlineTrainer) TrainDataset(ctx context.Context, n *deep.Neural, examples Dataset, validation Examples, iterations int) error {
+	t.internal = newTraining(n)
+
+	t.history = &History{}
+	t.printer.rate = learningRate(t.solver)
+	t.printer.regularizer = &t.regularizer
+	t.printer.metrics = t.metrics
 	t.solver.Init(n.NumWeights())
 
-	ts := time.Now()
-	for i := 1; i <= iterations; i++ {
-		examples.Shuffle()
-		for j := 0; j < len(examples); j++ {
-			t.learn(n, examples[j], i)
+	s := newSession(ctx, n, iterations, validation, t.metrics, t.solver, &t.regularizer,
+		append([]Callback{t.printer, t.history}, t.callbacks...))
+	s.trainBegin()
+
+	var err error
+	for i := 1; i <= iterations && !s.done(); i++ {
+		if err = examples.Reset(); err != nil {
+			break
+		}
+		s.epochBegin(i)
+		for j := 0; !s.done(); j++ {
+			var e Example
+			if e, err = examples.Next(); err != nil {
+				break
+			}
+			loss := t.learn(n, e, i)
+			s.batchEnd(j, loss, 1, t.gradNorm)
 		}
-		if t.verbosity > 0 && i%t.verbosity == 0 && len(validation) > 0 {
-			t.printer.PrintProgress(n, validation, time.Since(ts), i)
+		if err == io.EOF {
+			err = nil
 		}
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+		s.epochEnd()
+	}
+	s.trainEnd()
+	if err != nil {
+		return err
 	}
+	return ctx.Err()
 }
 
-func (t *OnlineTrainer) learn(n *deep.Neural, e Example, it int) {
+func (t *OnlineTrainer) learn(n *deep.Neural, e Example, it int) float64 {
 	n.Forward(e.Input)
+	loss := t.loss.of(n, e.Response)
 	t.calculateDeltas(n, e.Response)
 	t.update(n, it)
+	return loss
 }
 
 func (t *OnlineTrainer) calculateDeltas(n *deep.Neural, ideal []float64) {
-	for i, neuron := range n.Layers[len(n.Layers)-1].Neurons {
-		t.deltas[len(n.Layers)-1][i] = deep.GetLoss(n.Config.Loss).Df(
+	outputDeltas(n, ideal, t.deltas[len(n.Layers)-1])
+	n.Backpropagate(t.deltas)
+}
+
+// outputDeltas computes the deltas of the output layer of n following the
+// last forward pass
+func outputDeltas(n *deep.Neural, ideal, deltas []float64) {
+	last := n.Layers[len(n.Layers)-1]
+	for i, neuron := range last.Neurons {
+		deltas[i] = deep.GetLoss(n.Config.Loss).Df(
 			neuron.Value,
 			ideal[i],
 			neuron.DActivate(neuron.Value))
 	}
-
-	for i := len(n.Layers) - 2; i >= 0; i-- {
-		for j, neuron := range n.Layers[i].Neurons {
-			var sum float64
-			for k, s := range neuron.Out {
-				sum += s.Weight * t.deltas[i+1][k]
-			}
-			t.deltas[i][j] = neuron.DActivate(neuron.Value) * sum
-		}
+	// CE deltas already account for softmax, MSE deltas are taken with
+	// respect to its outputs
+	if last.A == deep.ActivationSoftmax && n.Config.Loss == deep.LossMeanSquared {
+		last.SoftmaxDeltas(deltas)
 	}
 }
 
 func (t *OnlineTrainer) update(n *deep.Neural, it int) {
-	var idx int
 	for i, l := range n.Layers {
-		for j := range l.Neurons {
-			for k := range l.Neurons[j].In {
-				update := t.solver.Update(l.Neurons[j].In[k].Weight,
-					t.deltas[i][j]*l.Neurons[j].In[k].In,
-					it,
-					idx)
-				l.Neurons[j].In[k].Weight += update
+		gradients := t.params.gradients[i]
+		var idx int
+		for j, n := range l.Neurons {
+			for _, s := range n.In {
+				gradients[idx] = t.deltas[i][j] * s.In
 				idx++
 			}
 		}
 	}
+	t.regularizer.gradients(n, t.params, 1)
+	t.gradNorm = t.params.clip(t.clipping)
+	t.params.update(n, t.solver, it)
+	t.regularizer.constrain(n)
 }
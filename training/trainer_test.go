@@ -242,3 +242,65 @@ func Test_xor(t *testing.T) {
 func printResult(ideal, actual []float64) {
 	fmt.Printf("want: %+v have: %+v\n", ideal, actual)
 }
+
+func Test_Solvers(t *testing.T) {
+	solvers := map[string]func() Solver{
+		"rmsprop":  func() Solver { return NewRMSProp(0.01, 0, 0) },
+		"adagrad":  func() Solver { return NewAdagrad(0.5, 0) },
+		"adadelta": func() Solver { return NewAdadelta(1, 0.99, 0) },
+		"nadam":    func() Solver { return NewNadam(0.05, 0, 0, 0) },
+		"adamw":    func() Solver { return NewAdamW(0.05, 0, 0, 0, 1e-4) },
+		"amsgrad":  func() Solver { return NewAMSGrad(0.05, 0, 0, 0) },
+	}
+
+	xor := Examples{
+		{[]float64{0, 0}, []float64{0}},
+		{[]float64{1, 0}, []float64{1}},
+		{[]float64{0, 1}, []float64{1}},
+		{[]float64{1, 1}, []float64{0}},
+	}
+	var sine Examples
+	for i := 0.0; i < 1; i += 0.01 {
+		sine = append(sine, Example{Input: []float64{i}, Response: []float64{math.Sin(i)}})
+	}
+
+	for name, solver := range solvers {
+		rand.Seed(0)
+		n := deep.NewNeural(&deep.Config{
+			Inputs:     2,
+			Layout:     []int{5, 1},
+			Activation: deep.ActivationSigmoid,
+			Mode:       deep.ModeBinary,
+			Weight:     deep.NewUniform(1, 0),
+			Bias:       true,
+		})
+		NewTrainer(solver(), 0).Train(n, xor, nil, 2000)
+		for _, e := range xor {
+			assert.InDelta(t, e.Response[0], n.Predict(e.Input)[0], 0.2, "%s xor %v", name, e.Input)
+		}
+
+		rand.Seed(0)
+		n = deep.NewNeural(&deep.Config{
+			Inputs:     1,
+			Layout:     []int{4, 4, 1},
+			Activation: deep.ActivationTanh,
+			Mode:       deep.ModeRegression,
+			Weight:     deep.NewUniform(0.5, 0),
+			Bias:       true,
+		})
+		NewBatchTrainer(solver(), 0, 10, 1).Train(n, sine, nil, 1000)
+		for _, x := range []float64{0.1, 0.25, 0.5, 0.75, 0.9} {
+			assert.InDelta(t, math.Sin(x), n.Predict([]float64{x})[0], 0.05, "%s sine %v", name, x)
+		}
+	}
+}
+
+func Test_AdamWDecoupledDecay(t *testing.T) {
+	adam, adamw := NewAdam(0.1, 0, 0, 0), NewAdamW(0.1, 0, 0, 0, 0.5)
+	adam.Init(1)
+	adamw.Init(1)
+
+	// Decay does not pass through the gradient moments
+	assert.InDelta(t, adam.Update(2, 1, 1, 0)-0.1*0.5*2, adamw.Update(2, 1, 1, 0), 1e-12)
+	assert.InDelta(t, -0.1*0.5*2, adamw.Update(2, 0, 2, 0)-adam.Update(2, 0, 2, 0), 1e-12)
+}
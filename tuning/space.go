@@ -0,0 +1,191 @@
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Param is a hyperparameter to search over
+type Param interface {
+	// Sample draws a random value
+	Sample(r *rand.Rand) interface{}
+	// Grid returns the values tried by a grid search
+	Grid() []interface{}
+}
+
+// Space declares the hyperparameters to search by name
+type Space map[string]Param
+
+// Choice is one of a set of values, e.g. layouts or activations
+type Choice []interface{}
+
+// Sample draws a value uniformly
+func (c Choice) Sample(r *rand.Rand) interface{} {
+	return c[r.Intn(len(c))]
+}
+
+// Grid returns every value
+func (c Choice) Grid() []interface{} {
+	return c
+}
+
+// Uniform is a real value in [Min, Max]
+type Uniform struct {
+	Min, Max float64
+	// Steps is the number of evenly spaced values in a grid, 5 by default
+	Steps int
+}
+
+// Sample draws a value uniformly
+func (u Uniform) Sample(r *rand.Rand) interface{} {
+	return u.Min + r.Float64()*(u.Max-u.Min)
+}
+
+// Grid returns evenly spaced values including both bounds
+func (u Uniform) Grid() []interface{} {
+	return steps(u.Min, u.Max, u.Steps, func(x float64) float64 { return x })
+}
+
+// LogUniform is a real value in [Min, Max] with a uniformly distributed
+// logarithm, suited to learning rates and regularization strengths
+type LogUniform struct {
+	Min, Max float64
+	// Steps is the number of log spaced values in a grid, 5 by default
+	Steps int
+}
+
+// Sample draws a value log-uniformly
+func (u LogUniform) Sample(r *rand.Rand) interface{} {
+	return math.Exp(math.Log(u.Min) + r.Float64()*(math.Log(u.Max)-math.Log(u.Min)))
+}
+
+// Grid returns log spaced values including both bounds
+func (u LogUniform) Grid() []interface{} {
+	return steps(math.Log(u.Min), math.Log(u.Max), u.Steps, math.Exp)
+}
+
+// IntRange is an integer in [Min, Max], e.g. a batch size
+type IntRange struct {
+	Min, Max int
+}
+
+// Sample draws a value uniformly
+func (i IntRange) Sample(r *rand.Rand) interface{} {
+	return i.Min + r.Intn(i.Max-i.Min+1)
+}
+
+// Grid returns every integer in the range
+func (i IntRange) Grid() []interface{} {
+	res := make([]interface{}, 0, i.Max-i.Min+1)
+	for v := i.Min; v <= i.Max; v++ {
+		res = append(res, v)
+	}
+	return res
+}
+
+func steps(min, max float64, n int, f func(float64) float64) []interface{} {
+	if n <= 0 {
+		n = 5
+	}
+	if n == 1 {
+		return []interface{}{f(min)}
+	}
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i] = f(min + float64(i)*(max-min)/float64(n-1))
+	}
+	return res
+}
+
+// Params is an assignment of values to hyperparameters
+type Params map[string]interface{}
+
+// Float returns a real valued parameter
+func (p Params) Float(name string) float64 {
+	switch v := p[name].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	panic(fmt.Sprintf("tuning: parameter %s is not a number", name))
+}
+
+// Int returns an integer parameter
+func (p Params) Int(name string) int {
+	switch v := p[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(math.Round(v))
+	}
+	panic(fmt.Sprintf("tuning: parameter %s is not a number", name))
+}
+
+// Ints returns a parameter holding a slice of integers, e.g. a layout
+func (p Params) Ints(name string) []int {
+	return p[name].([]int)
+}
+
+func (p Params) String() string {
+	names := p.names()
+	parts := make([]string, len(names))
+	for i, name := range names {
+		v := p[name]
+		if f, ok := v.(float64); ok {
+			v = fmt.Sprintf("%.4g", f)
+		}
+		parts[i] = fmt.Sprintf("%s=%v", name, v)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p Params) names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s Space) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// grid returns the cartesian product of the grid values of every parameter
+func (s Space) grid() []Params {
+	res := []Params{{}}
+	for _, name := range s.names() {
+		values := s[name].Grid()
+		next := make([]Params, 0, len(res)*len(values))
+		for _, p := range res {
+			for _, v := range values {
+				q := make(Params, len(p)+1)
+				for k, w := range p {
+					q[k] = w
+				}
+				q[name] = v
+				next = append(next, q)
+			}
+		}
+		res = next
+	}
+	return res
+}
+
+func (s Space) sample(r *rand.Rand) Params {
+	p := make(Params, len(s))
+	for _, name := range s.names() {
+		p[name] = s[name].Sample(r)
+	}
+	return p
+}
@@ -0,0 +1,42 @@
+package tuning
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Grid(t *testing.T) {
+	s := Space{
+		"layout": Choice{[]int{2}, []int{4}},
+		"rate":   LogUniform{Min: 0.001, Max: 0.1, Steps: 3},
+		"batch":  IntRange{1, 2},
+	}
+	grid := s.grid()
+	assert.Len(t, grid, 12)
+	assert.Equal(t, 1, grid[0].Int("batch"))
+	assert.Equal(t, []int{2}, grid[0].Ints("layout"))
+	assert.InDelta(t, 0.001, grid[0].Float("rate"), 1e-12)
+	assert.InDelta(t, 0.01, grid[1].Float("rate"), 1e-12)
+	assert.Equal(t, "batch=1 layout=[2] rate=0.1", grid[2].String())
+
+	assert.Equal(t, []interface{}{0.0, 0.5, 1.0}, Uniform{0, 1, 3}.Grid())
+	assert.Len(t, Uniform{Min: 0, Max: 1}.Grid(), 5)
+}
+
+func Test_Sample(t *testing.T) {
+	s := Space{
+		"rate":    LogUniform{Min: 0.001, Max: 0.1},
+		"dropout": Uniform{Min: 0.1, Max: 0.2},
+		"batch":   IntRange{8, 10},
+	}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		p := s.sample(r)
+		assert.True(t, p.Float("rate") >= 0.001 && p.Float("rate") <= 0.1)
+		assert.True(t, p.Float("dropout") >= 0.1 && p.Float("dropout") <= 0.2)
+		assert.True(t, p.Int("batch") >= 8 && p.Int("batch") <= 10)
+	}
+	assert.Panics(t, func() { Params{"a": "x"}.Float("a") })
+}
@@ -0,0 +1,312 @@
+// Package tuning searches hyperparameters of networks and their trainers
+package tuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+)
+
+// Search configures a hyperparameter search
+type Search struct {
+	Space Space
+	// Config returns the network configuration of a trial
+	Config func(p Params) *deep.Config
+	// Trainer returns the trainer of a trial
+	Trainer func(p Params) training.Trainer
+	// Iterations is the number of epochs of a trial, and the largest
+	// budget of successive halving and Hyperband
+	Iterations int
+	// Validation scores trials, if nil they are scored by k-fold
+	// cross-validation over the training examples
+	Validation training.Examples
+	// Folds is the k of cross-validation, 5 by default
+	Folds int
+	// Metrics are computed for each trial, DefaultMetrics if nil
+	Metrics []training.Metric
+	// Objective names the metric ranking trials, the loss by default
+	Objective string
+	// Maximize ranks higher objective values first
+	Maximize bool
+	// Parallelism is the number of trials run concurrently
+	Parallelism int
+	// Seed seeds the sampling of random search and Hyperband
+	Seed int64
+}
+
+// Trial is an evaluated assignment of hyperparameters
+type Trial struct {
+	Params     Params
+	Iterations int
+	Score      float64
+	Metrics    map[string]float64
+	// Dump is the trained network, when cross-validating the network of
+	// the best trial is retrained on all examples
+	Dump *deep.Dump
+}
+
+// Leaderboard holds trials ranked best first
+type Leaderboard struct {
+	Trials    []Trial
+	Objective string
+}
+
+// Best returns the best trial
+func (l *Leaderboard) Best() *Trial {
+	if len(l.Trials) == 0 {
+		return nil
+	}
+	return &l.Trials[0]
+}
+
+func (l *Leaderboard) String() string {
+	var b strings.Builder
+	objective := l.Objective
+	if objective != "" {
+		objective = strings.ToUpper(objective[:1]) + objective[1:]
+	}
+	fmt.Fprintf(&b, "%-5s %-10s %-10s %s\n", "Rank", objective, "Epochs", "Params")
+	for i, t := range l.Trials {
+		fmt.Fprintf(&b, "%-5d %-10.4f %-10d %s\n", i+1, t.Score, t.Iterations, t.Params)
+	}
+	return b.String()
+}
+
+// Grid evaluates every combination of the grid values of the space
+func (s *Search) Grid(ctx context.Context, examples training.Examples) (*Leaderboard, error) {
+	params := s.Space.grid()
+	trials := make([]Trial, len(params))
+	for i, p := range params {
+		trials[i] = Trial{Params: p, Iterations: s.Iterations}
+	}
+	return s.leaderboard(ctx, examples, trials)
+}
+
+// Random evaluates n random samples of the space
+func (s *Search) Random(ctx context.Context, examples training.Examples, n int) (*Leaderboard, error) {
+	r := rand.New(rand.NewSource(s.Seed))
+	trials := make([]Trial, n)
+	for i := range trials {
+		trials[i] = Trial{Params: s.Space.sample(r), Iterations: s.Iterations}
+	}
+	return s.leaderboard(ctx, examples, trials)
+}
+
+// SuccessiveHalving evaluates n random samples on a small budget, and
+// repeatedly keeps the best 1/eta of them, at least one, for an eta times
+// larger budget, until reaching Iterations. Trials are retrained from
+// scratch on every budget, and only those of the final rung, trained for
+// Iterations, are ranked.
+func (s *Search) SuccessiveHalving(ctx context.Context, examples training.Examples, n, eta int) (*Leaderboard, error) {
+	eta = iparam(eta, 3)
+	r := rand.New(rand.NewSource(s.Seed))
+	trials, err := s.halve(ctx, examples, r, n, eta, halvings(s.Iterations, eta))
+	if err != nil {
+		return nil, err
+	}
+	return s.finish(ctx, examples, trials)
+}
+
+// Hyperband runs brackets of successive halving, trading off the number
+// of sampled trials against their initial budget. The final rungs of all
+// brackets are ranked together.
+func (s *Search) Hyperband(ctx context.Context, examples training.Examples, eta int) (*Leaderboard, error) {
+	eta = iparam(eta, 3)
+	r := rand.New(rand.NewSource(s.Seed))
+	max := halvings(s.Iterations, eta)
+
+	var trials []Trial
+	for rungs := max; rungs >= 0; rungs-- {
+		n := int(math.Ceil(float64(max+1) / float64(rungs+1) * math.Pow(float64(eta), float64(rungs))))
+		bracket, err := s.halve(ctx, examples, r, n, eta, rungs)
+		if err != nil {
+			return nil, err
+		}
+		trials = append(trials, bracket...)
+	}
+	return s.finish(ctx, examples, trials)
+}
+
+// halvings is the largest k such that eta^k <= iterations
+func halvings(iterations, eta int) int {
+	k := 0
+	for budget := eta; budget <= iterations; budget *= eta {
+		k++
+	}
+	return k
+}
+
+// halve runs successive halving of n samples over rungs halvings,
+// returning the trials of the final rung
+func (s *Search) halve(ctx context.Context, examples training.Examples, r *rand.Rand, n, eta, rungs int) ([]Trial, error) {
+	candidates := make([]Trial, n)
+	for i := range candidates {
+		candidates[i] = Trial{Params: s.Space.sample(r)}
+	}
+
+	var evaluated []Trial
+	for rung := 0; rung <= rungs && len(candidates) > 0; rung++ {
+		budget := s.Iterations / int(math.Pow(float64(eta), float64(rungs-rung)))
+		for i := range candidates {
+			candidates[i].Iterations = iparam(budget, 1)
+		}
+		var err error
+		if evaluated, err = s.run(ctx, examples, candidates); err != nil {
+			return nil, err
+		}
+		s.rank(evaluated)
+
+		keep := len(evaluated) / eta
+		if keep == 0 {
+			keep = 1
+		}
+		candidates = make([]Trial, keep)
+		for i := range candidates {
+			candidates[i] = Trial{Params: evaluated[i].Params}
+		}
+	}
+	return evaluated, nil
+}
+
+func (s *Search) leaderboard(ctx context.Context, examples training.Examples, trials []Trial) (*Leaderboard, error) {
+	trials, err := s.run(ctx, examples, trials)
+	if err != nil {
+		return nil, err
+	}
+	return s.finish(ctx, examples, trials)
+}
+
+// finish ranks trials, retraining the best on all examples if they were
+// cross-validated
+func (s *Search) finish(ctx context.Context, examples training.Examples, trials []Trial) (*Leaderboard, error) {
+	s.rank(trials)
+	l := &Leaderboard{Trials: trials, Objective: s.objective()}
+	if best := l.Best(); best != nil && best.Dump == nil {
+		n := deep.NewNeural(s.config(best.Params))
+		if err := train(ctx, s.Trainer(best.Params), n, examples, nil, best.Iterations); err != nil {
+			return nil, err
+		}
+		best.Dump = n.Dump()
+	}
+	return l, nil
+}
+
+// config returns a copy of the configuration of a trial, as NewNeural
+// fills in the defaults of the configuration it is given
+func (s *Search) config(p Params) *deep.Config {
+	c := *s.Config(p)
+	return &c
+}
+
+// train trains n, stopping once ctx is done if the trainer supports it
+func train(ctx context.Context, trainer training.Trainer, n *deep.Neural, examples, validation training.Examples, iterations int) error {
+	if t, ok := trainer.(interface {
+		TrainContext(context.Context, *deep.Neural, training.Examples, training.Examples, int) error
+	}); ok {
+		return t.TrainContext(ctx, n, examples, validation, iterations)
+	}
+	trainer.Train(n, examples, validation, iterations)
+	return ctx.Err()
+}
+
+func (s *Search) objective() string {
+	if s.Objective == "" {
+		return "loss"
+	}
+	return s.Objective
+}
+
+func (s *Search) rank(trials []Trial) {
+	sort.SliceStable(trials, func(i, j int) bool {
+		a, b := trials[i].Score, trials[j].Score
+		if math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		if s.Maximize {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+// run evaluates trials concurrently
+func (s *Search) run(ctx context.Context, examples training.Examples, trials []Trial) ([]Trial, error) {
+	if len(examples) == 0 {
+		return nil, errors.New("tuning: no examples")
+	}
+	res := make([]Trial, len(trials))
+	errs := make([]error, len(trials))
+	sem := make(chan struct{}, iparam(s.Parallelism, 1))
+	var wg sync.WaitGroup
+	for i := range trials {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res[i], errs[i] = s.evaluate(ctx, examples, trials[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (s *Search) evaluate(ctx context.Context, examples training.Examples, t Trial) (Trial, error) {
+	if err := ctx.Err(); err != nil {
+		return t, err
+	}
+	var loss float64
+	if s.Validation == nil {
+		cv := &training.CrossValidation{
+			Config:     func() *deep.Config { return s.config(t.Params) },
+			Trainer:    func() training.Trainer { return s.Trainer(t.Params) },
+			Iterations: t.Iterations,
+			Metrics:    s.Metrics,
+		}
+		res, err := cv.Run(ctx, examples, iparam(s.Folds, 5))
+		if err != nil {
+			return t, err
+		}
+		loss, t.Metrics = res.Mean["loss"], res.Mean
+		delete(t.Metrics, "loss")
+	} else {
+		n := deep.NewNeural(s.config(t.Params))
+		if err := train(ctx, s.Trainer(t.Params), n, examples, s.Validation, t.Iterations); err != nil {
+			return t, err
+		}
+		loss, t.Metrics = training.Validate(n, s.Validation, s.Metrics)
+		t.Dump = n.Dump()
+	}
+
+	t.Score = loss
+	if s.objective() != "loss" {
+		v, ok := t.Metrics[s.Objective]
+		if !ok {
+			return t, fmt.Errorf("tuning: unknown objective %q", s.Objective)
+		}
+		t.Score = v
+	}
+	return t, nil
+}
+
+func iparam(val, def int) int {
+	if val == 0 {
+		return def
+	}
+	return val
+}
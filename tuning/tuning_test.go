@@ -0,0 +1,161 @@
+package tuning
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+	"github.com/stretchr/testify/assert"
+)
+
+func xor() training.Examples {
+	var res training.Examples
+	for i := 0; i < 5; i++ {
+		res = append(res,
+			training.Example{Input: []float64{0, 0}, Response: []float64{0}},
+			training.Example{Input: []float64{0, 1}, Response: []float64{1}},
+			training.Example{Input: []float64{1, 0}, Response: []float64{1}},
+			training.Example{Input: []float64{1, 1}, Response: []float64{0}},
+		)
+	}
+	return res
+}
+
+func search() *Search {
+	return &Search{
+		Space: Space{
+			"rate":   Choice{0.0, 0.1},
+			"layout": Choice{[]int{4, 1}},
+		},
+		Config: func(p Params) *deep.Config {
+			return &deep.Config{
+				Inputs:     2,
+				Layout:     p.Ints("layout"),
+				Activation: deep.ActivationTanh,
+				Mode:       deep.ModeBinary,
+				Weight:     deep.NewNormal(1, 0),
+				Bias:       true,
+			}
+		},
+		Trainer: func(p Params) training.Trainer {
+			return training.NewTrainer(training.NewAdam(p.Float("rate"), 0, 0, 0), 0)
+		},
+		Iterations:  30,
+		Validation:  xor(),
+		Parallelism: 2,
+	}
+}
+
+func Test_GridSearch(t *testing.T) {
+	s := search()
+	l, err := s.Grid(context.Background(), xor())
+	assert.NoError(t, err)
+	assert.Len(t, l.Trials, 2)
+	// A learning rate of 0 cannot improve on the initial weights
+	assert.Equal(t, 0.1, l.Best().Params.Float("rate"))
+	assert.True(t, l.Trials[0].Score <= l.Trials[1].Score)
+	assert.NotNil(t, l.Best().Dump)
+	assert.Contains(t, l.Best().Metrics, "accuracy")
+	assert.Contains(t, l.String(), "rate=0.1")
+
+	s.Objective, s.Maximize = "accuracy", true
+	l, err = s.Grid(context.Background(), xor())
+	assert.NoError(t, err)
+	assert.True(t, l.Trials[0].Score >= l.Trials[1].Score)
+
+	s.Objective = "unknown"
+	_, err = s.Grid(context.Background(), xor())
+	assert.Error(t, err)
+}
+
+func Test_RandomSearch(t *testing.T) {
+	s := search()
+	s.Validation, s.Folds = nil, 2
+	l, err := s.Random(context.Background(), xor(), 3)
+	assert.NoError(t, err)
+	assert.Len(t, l.Trials, 3)
+	// Cross-validated trials are refit on all examples
+	n := deep.FromDump(l.Best().Dump)
+	assert.Len(t, n.Predict([]float64{0, 1}), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Random(ctx, xor(), 3)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_Hyperband(t *testing.T) {
+	s := search()
+	s.Iterations = 9
+
+	// 9 trials of 1 epoch, 3 of 3 and 1 of 9, of which the last is ranked
+	l, err := s.SuccessiveHalving(context.Background(), xor(), 9, 3)
+	assert.NoError(t, err)
+	assert.Len(t, l.Trials, 1)
+	assert.Equal(t, 9, l.Best().Iterations)
+
+	// Fewer than eta trials still reach the full budget
+	l, err = s.SuccessiveHalving(context.Background(), xor(), 2, 3)
+	assert.NoError(t, err)
+	assert.Len(t, l.Trials, 1)
+	assert.Equal(t, 9, l.Best().Iterations)
+
+	l, err = s.Hyperband(context.Background(), xor(), 3)
+	assert.NoError(t, err)
+	// The final rungs of brackets of 9, 5 and 3 trials
+	assert.Len(t, l.Trials, 5)
+	for _, trial := range l.Trials {
+		assert.Equal(t, 9, trial.Iterations)
+	}
+	assert.NotNil(t, l.Best().Dump)
+
+	// The best network is trained for the full budget when cross-validating
+	s.Validation, s.Folds = nil, 2
+	trained := &budgets{}
+	s.Trainer = func(p Params) training.Trainer {
+		return &recordingTrainer{Trainer: training.NewTrainer(training.NewAdam(p.Float("rate"), 0, 0, 0), 0), budgets: trained}
+	}
+	l, err = s.SuccessiveHalving(context.Background(), xor(), 3, 3)
+	assert.NoError(t, err)
+	assert.NotNil(t, l.Best().Dump)
+	assert.Equal(t, 9, trained.last)
+}
+
+// budgets records the iterations of the last training run
+type budgets struct {
+	sync.Mutex
+	last int
+}
+
+type recordingTrainer struct {
+	training.Trainer
+	budgets *budgets
+}
+
+func (t *recordingTrainer) Train(n *deep.Neural, examples, validation training.Examples, iterations int) {
+	t.budgets.Lock()
+	t.budgets.last = iterations
+	t.budgets.Unlock()
+	t.Trainer.Train(n, examples, validation, iterations)
+}
+
+func Test_SearchIsolation(t *testing.T) {
+	s := search()
+	// A configuration shared between trials is copied for each
+	shared := &deep.Config{Inputs: 2, Layout: []int{4, 1}, Mode: deep.ModeBinary, Bias: true}
+	s.Config = func(p Params) *deep.Config { return shared }
+	l, err := s.Grid(context.Background(), xor())
+	assert.NoError(t, err)
+	assert.Nil(t, shared.Weight)
+	assert.Equal(t, deep.LossNone, shared.Loss)
+
+	// Trials are scored as the trainers validate
+	for _, trial := range l.Trials {
+		loss, metrics := training.Validate(deep.FromDump(trial.Dump), s.Validation, nil)
+		assert.InDelta(t, loss, trial.Score, 1e-12)
+		assert.Equal(t, metrics, trial.Metrics)
+	}
+	assert.Contains(t, l.String(), "Loss")
+}